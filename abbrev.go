@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// addressAbbreviation 住所の略記の規則
+type addressAbbreviation struct {
+	Pattern *regexp.Regexp
+	Replace string
+}
+
+// defaultAddressAbbreviations 配送業者に通じる住所の略記のデフォルト
+// 数字に挟まれた丁目・番地・番はハイフンに、数字の後ろの号・号室は取り除く。「2号棟」のように後ろに続く号は残す
+var defaultAddressAbbreviations = []addressAbbreviation{
+	{regexp.MustCompile(`(\p{Nd})(?:丁目|番地|番)(\p{Nd})`), "$1-$2"},
+	{regexp.MustCompile(`(\p{Nd})号室?($|[\s　])`), "$1$2"},
+	{regexp.MustCompile(`マンション`), "M"},
+}
+
+// LoadAddressAbbreviations 住所の略記の対応付けを読み込む。キーの文字列を値に置き換える
+// 例: {"ハイツ": "H", "レジデンス": "R"}
+func LoadAddressAbbreviations(filename string) (map[string]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", filename, err)
+	}
+	for from := range m {
+		if from == "" {
+			return nil, fmt.Errorf("%sに置き換える文字列が空の対応付けがあります", filename)
+		}
+	}
+	return m, nil
+}
+
+// abbreviateAddress 住所の行を略記する。デフォルトの規則の後に、Abbreviationsの対応付けを長いキーから順に置き換える
+// 数字が続く住所（1丁目2番3号）は1回の置き換えで重なった部分が残るので、変わらなくなるまで繰り返す
+func (o ConvertOptions) abbreviateAddress(s string) string {
+	for _, a := range defaultAddressAbbreviations {
+		for {
+			replaced := a.Pattern.ReplaceAllString(s, a.Replace)
+			if replaced == s {
+				break
+			}
+			s = replaced
+		}
+	}
+	keys := make([]string, 0, len(o.Abbreviations))
+	for from := range o.Abbreviations {
+		keys = append(keys, from)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	for _, from := range keys {
+		s = strings.ReplaceAll(s, from, o.Abbreviations[from])
+	}
+	return s
+}
+
+// AbbreviatedOrders 住所を略記した注文を返す。略記しない場合の住所と比べる
+// 略記で読み違えが起きていないか確かめられるよう、注意として知らせる
+func AbbreviatedOrders(orders []*ShopifyOrder, opts ConvertOptions) []*ShopifyOrder {
+	if !opts.AbbreviateAddress {
+		return nil
+	}
+	// 比べるための変換なので、変換の過程は記録しない
+	opts.Trace = nil
+	plain := opts
+	plain.AbbreviateAddress = false
+	var abbreviated []*ShopifyOrder
+	for _, o := range orders {
+		if addressText(o.ToClickpostShippingLabel(opts)) != addressText(o.ToClickpostShippingLabel(plain)) {
+			abbreviated = append(abbreviated, o)
+		}
+	}
+	return abbreviated
+}
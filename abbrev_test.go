@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAbbreviateAddress デフォルトの規則と、追加の対応付けを長いキーから順に置き換える
+func TestAbbreviateAddress(t *testing.T) {
+	tests := []struct {
+		name          string
+		abbreviations map[string]string
+		line          string
+		want          string
+	}{
+		{name: "丁目・番・号", line: "神南1丁目2番3号", want: "神南1-2-3"},
+		{name: "数字に挟まれていない番地は残す", line: "北一条西2丁目1番地", want: "北一条西2-1番地"},
+		{name: "後ろに数字のない丁目は残す", line: "1丁目", want: "1丁目"},
+		{name: "号棟は残して号室を取り除く", line: "2号棟101号室", want: "2号棟101"},
+		{name: "マンション", line: "渋谷マンション301号室", want: "渋谷M301"},
+		{name: "追加の対応付けがなければ変えない", line: "渋谷レジデンスハイツ", want: "渋谷レジデンスハイツ"},
+		{
+			name:          "追加の対応付けは長いキーから",
+			abbreviations: map[string]string{"ハイツ": "H", "レジデンスハイツ": "RH", "レジデンス": "R"},
+			line:          "渋谷レジデンスハイツ 渋谷レジデンス",
+			want:          "渋谷RH 渋谷R",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			opts.AbbreviateAddress, opts.Abbreviations = true, tt.abbreviations
+			if got := opts.abbreviateAddress(tt.line); got != tt.want {
+				t.Errorf("abbreviateAddress(%q) = %q、%qを期待", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAbbreviateAddressFitsLimit 文字数の上限を超える住所が、略記すると検証に通り、略記した注文として知らせる
+func TestAbbreviateAddressFitsLimit(t *testing.T) {
+	long := &ShopifyOrder{
+		Name: "#1", ShippingName: "山田太郎", ShippingZip: "150-0041",
+		ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "神南", ShippingAddress1: "1丁目2番3号",
+		ShippingAddress2: "渋谷グランドレジデンスマンション301号室",
+	}
+	short := &ShopifyOrder{
+		Name: "#2", ShippingName: "佐藤花子", ShippingZip: "150-0042",
+		ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "宇田川町", ShippingAddress1: "1-1",
+	}
+	opts := DefaultConvertOptions()
+	if err := long.ToClickpostShippingLabel(opts).Validate(); err == nil {
+		t.Fatal("略記しない住所が検証に通りました。上限を超える住所を期待")
+	}
+
+	opts.AbbreviateAddress = true
+	opts.Abbreviations = map[string]string{"レジデンス": "R"}
+	l := long.ToClickpostShippingLabel(opts)
+	if err := l.Validate(); err != nil {
+		t.Errorf("略記した送り状のValidate() = %v、nilを期待", err)
+	}
+	if l.ShippingAddress2 != "神南1-2-3" || l.ShippingAddress3 != "渋谷グランドRM301" {
+		t.Errorf("住所2・3行目 = %q %q、「神南1-2-3」「渋谷グランドRM301」を期待", l.ShippingAddress2, l.ShippingAddress3)
+	}
+	abbreviated := AbbreviatedOrders([]*ShopifyOrder{long, short}, opts)
+	if len(abbreviated) != 1 || abbreviated[0] != long {
+		t.Errorf("略記した注文 = %v、#1だけを期待", abbreviated)
+	}
+	opts.AbbreviateAddress = false
+	if got := AbbreviatedOrders([]*ShopifyOrder{long, short}, opts); got != nil {
+		t.Errorf("-abbreviate-addressなしの略記した注文 = %v、nilを期待", got)
+	}
+}
+
+// TestLoadAddressAbbreviations 対応付けのJSONを読み込み、置き換える文字列が空の対応付けはエラーにする
+func TestLoadAddressAbbreviations(t *testing.T) {
+	dir := t.TempDir()
+	valid, empty := filepath.Join(dir, "valid.json"), filepath.Join(dir, "empty.json")
+	if err := os.WriteFile(valid, []byte(`{"ハイツ": "H"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(empty, []byte(`{"": "H"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadAddressAbbreviations(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["ハイツ"] != "H" {
+		t.Errorf("対応付け = %v、ハイツ→Hを期待", m)
+	}
+	if _, err := LoadAddressAbbreviations(empty); err == nil {
+		t.Error("空のキーの対応付けがエラーになりません")
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// AddressStyle Shopifyの住所欄の書式
+type AddressStyle string
+
+const (
+	// AddressStyleJP 日本式。都道府県・市区町村・町名・番地・建物名がそれぞれの欄に日本語の順で入っている
+	AddressStyleJP AddressStyle = "jp"
+	// AddressStyleEN 英語式。住所を英語圏の書式のまま受け付けたストア向け
+	//
+	// 次の並びを想定している
+	//   Shipping Address1: "1-2-3 Jinnan" のように番地が先頭、町名が後ろ
+	//   Shipping Address2: "Room 301" などの建物名・部屋番号
+	//   Shipping City:     "Shibuya-ku" などの市区町村
+	//   Shipping Province: "Tokyo" などのローマ字の都道府県名
+	// 都道府県は漢字表記に変換し、Address1は番地を末尾に移して町名→番地の順に並べ替える
+	AddressStyleEN AddressStyle = "en"
+	// AddressStyleSingle 1つの欄に住所をまとめて入力させるストア向け
+	//
+	// Shipping Province・Shipping City・Shipping Streetが空欄の注文は、Shipping Address1に都道府県から建物名までが入っているとみなし、
+	// Shipping Address2と合わせて住所1〜4行目に文字数で分ける。どれかの欄が入力されている注文は日本式と同じ
+	AddressStyleSingle AddressStyle = "single"
+)
+
+// ParseAddressStyle 文字列から住所の書式を返す
+func ParseAddressStyle(s string) (AddressStyle, error) {
+	switch style := AddressStyle(s); style {
+	case AddressStyleJP, AddressStyleEN, AddressStyleSingle:
+		return style, nil
+	}
+	return "", fmt.Errorf("住所の書式はjp、en、singleのどれかを指定してください: %s", s)
+}
+
+// toJapaneseAddressOrder 英語式の住所を日本の郵便の順（都道府県→市区町村→町名・番地→建物名）に並べ替えた注文データを返す
+func (s ShopifyOrder) toJapaneseAddressOrder() ShopifyOrder {
+	if prefecture, ok := LookupPrefecture(s.ShippingProvince); ok {
+		s.ShippingProvince = prefecture
+	}
+	s.ShippingAddress1 = moveLeadingNumberToEnd(s.ShippingAddress1)
+	return s
+}
+
+// moveLeadingNumberToEnd "1-2-3 Jinnan" のように先頭にある番地を末尾に移す
+func moveLeadingNumberToEnd(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || !strings.ContainsFunc(fields[0], unicode.IsDigit) {
+		return s
+	}
+	return strings.Join(append(fields[1:], fields[0]), " ")
+}
+
+// hyphenReplacer ハイフンに似た文字をASCIIのハイフンに揃える
+var hyphenReplacer = strings.NewReplacer("－", "-", "―", "-", "‐", "-", "−", "-", "ー", "-", "—", "-", "–", "-")
+
+// normalizeNumberField 郵便番号や電話番号の全角の数字とハイフンに似た文字をASCIIに揃える
+// 「１５０−０００１」は「150-0001」になる。書式の検証より前に通す
+func normalizeNumberField(s string) string {
+	return hyphenReplacer.Replace(width.Fold.String(strings.TrimSpace(s)))
+}
+
+// normalizeAddressKey 住所を比較するために全角半角・空白・ハイフンの違いを吸収する
+func normalizeAddressKey(s string) string {
+	s = width.Fold.String(s)
+	s = strings.Join(strings.Fields(s), "")
+	return hyphenReplacer.Replace(s)
+}
+
+// ComposeAddressLines 送り状の住所1〜4行目を組み立てる
+//
+//	1行目: 都道府県+市区町村
+//	2行目: 町名+番地（Shipping Street+Shipping Address1）
+//	3行目: 建物名など（Shipping Address2）
+//
+// SplitBuildingが有効で、Shipping Address1に建物名が含まれる場合は建物名を3行目、Shipping Address2を4行目にする
+// CompactAddressが有効な場合は、最後に空の行を詰める
+// 住所の書式がsingleで構造化された欄が空欄の場合は、1つの欄の住所を文字数で分ける
+// 送り状の構造体によらず、1行の文字数などの上限はopts.Carrierの配送業者のものを使うので、どの配送業者の変換からもこれを呼ぶ
+// oは書き換えない
+func ComposeAddressLines(o *ShopifyOrder, opts ConvertOptions) [4]string {
+	s := *o
+	if opts.NormalizeRoom {
+		// Shipping Address2は住所3行目に入ることが多いので、3行目の上限に収める
+		if room, ok := NormalizeRoomNumber(s.ShippingAddress2, opts.carrier().maxLen("ShippingAddress3")); ok {
+			opts.trace("", "Shipping Address2の部屋番号「%s」を「%s」にそろえる（-normalize-room）", maskedValue(s.ShippingAddress2), maskedValue(room))
+			s.ShippingAddress2 = room
+		}
+	}
+	if opts.AddressStyle == AddressStyleSingle && s.isSingleFieldAddress() {
+		return s.singleFieldAddressLines(opts)
+	}
+	street, deduped := opts.joinStreet(s.ShippingStreet, s.ShippingAddress1)
+	lines := [4]string{
+		s.ShippingProvince + s.ShippingCity,
+		street,
+		s.ShippingAddress2,
+	}
+	sources := [4]string{
+		"Shipping Province+Shipping City",
+		"Shipping Street+Shipping Address1",
+		"Shipping Address2",
+	}
+	if opts.SplitBuilding {
+		if banchi, building := SplitBuildingName(s.ShippingAddress1); building != "" {
+			street, deduped = opts.joinStreet(s.ShippingStreet, banchi)
+			lines[1], lines[2], lines[3] = street, building, s.ShippingAddress2
+			sources[1], sources[2], sources[3] = "Shipping Street+Shipping Address1の番地まで", "Shipping Address1の建物名（-split-building）", "Shipping Address2"
+		}
+	}
+	if deduped {
+		sources[1] += "（-dedupe-streetで重複を除く）"
+	}
+	if opts.SplitCareOf {
+		for i := 1; i < len(lines); i++ {
+			rest, careOf := SplitCareOf(lines[i])
+			if careOf == "" {
+				continue
+			}
+			if j := emptyLine(lines, i+1); j >= 0 {
+				lines[i], lines[j] = rest, careOf
+				sources[j] = sources[i] + "の様方（-split-care-of）"
+				sources[i] += "の様方より前"
+			} else {
+				opts.trace("", "住所に空いている行がないため、様方を別の行に分けない")
+			}
+			break
+		}
+	}
+	if opts.ArabicNumerals {
+		for i, line := range lines {
+			if normalized := NormalizeKanjiNumerals(line); normalized != line {
+				lines[i] = normalized
+				sources[i] += "（漢数字を算用数字に変換）"
+			}
+		}
+	}
+	if opts.AbbreviateAddress {
+		for i, line := range lines {
+			if abbreviated := opts.abbreviateAddress(line); abbreviated != line {
+				lines[i] = abbreviated
+				sources[i] += "（-abbreviate-addressで略記）"
+			}
+		}
+	}
+	if opts.NotesLine && s.Notes != "" {
+		// 配達の指示は改行を含むことが多いので、1行にしてから入れる
+		notes := strings.Join(strings.Fields(s.Notes), " ")
+		if i := emptyLine(lines, 2); i >= 0 {
+			lines[i], sources[i] = notes, "Notes（-notes-line）"
+		} else {
+			opts.trace("", "住所3・4行目が空いていないため、Notesを入れない")
+		}
+	}
+	if opts.CompactAddress {
+		lines, sources = compactAddressLines(lines, sources)
+	}
+	for i, source := range sources {
+		if source != "" {
+			opts.trace(addressLineFields[i], "%s", source)
+		}
+	}
+	return lines
+}
+
+// compactAddressLines 空の行を詰めて、住所を上の行から順に入れる。行の元の入力項目も一緒に移す
+// 空白だけの行は空の行とみなす
+func compactAddressLines(lines, sources [4]string) ([4]string, [4]string) {
+	var compacted, compactedSources [4]string
+	n := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		compacted[n], compactedSources[n] = line, sources[i]
+		if n != i {
+			compactedSources[n] += fmt.Sprintf("（空の行を詰めて%d行目から移動）", i+1)
+		}
+		n++
+	}
+	return compacted, compactedSources
+}
+
+// emptyLine from行目以降で最初の空いている行の添字。空いていない場合は-1
+func emptyLine(lines [4]string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if lines[i] == "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// addressLineFields 住所1〜4行目のフィールド名
+var addressLineFields = [4]string{"ShippingAddress1", "ShippingAddress2", "ShippingAddress3", "ShippingAddress4"}
+
+// buildingMarkers 建物名・部屋番号とみなす文字列
+var buildingMarkers = []string{"マンション", "ビル", "号室", "階", "ハイツ", "コーポ", "アパート", "レジデンス"}
+
+// banchiPattern 先頭から番地（1-2-3、2番3号など）までと、その後ろに分ける
+var banchiPattern = regexp.MustCompile(`^(.*?\p{Nd}+(?:(?:[-ー－−‐]|丁目|番地|番|号)\p{Nd}*)*)\s*(\S.*)$`)
+
+// careOfPattern 住所の中の「山田様方」のような気付の宛名
+var careOfPattern = regexp.MustCompile(`\s*([^\s　]+様方)\s*`)
+
+// SplitCareOf 「神南1-2-3 山田様方」のような住所を、様方の宛名とそれ以外に分ける
+// 行が様方の宛名だけの場合は、すでに別の行になっているので分けずに返す
+func SplitCareOf(s string) (rest, careOf string) {
+	m := careOfPattern.FindStringSubmatchIndex(s)
+	if m == nil {
+		return s, ""
+	}
+	rest = strings.TrimSpace(s[:m[0]] + " " + s[m[1]:])
+	if rest == "" {
+		return s, ""
+	}
+	return rest, s[m[2]:m[3]]
+}
+
+// SplitBuildingName 「神南1-2-3 渋谷マンション301」のような住所を番地までと建物名に分ける
+// 番地の後ろに建物名の目印（マンション、ビル、号室、階など）がない場合は分けずに返す
+func SplitBuildingName(s string) (street, building string) {
+	m := banchiPattern.FindStringSubmatch(s)
+	if m == nil || !containsAny(m[2], buildingMarkers) {
+		return s, ""
+	}
+	return m[1], m[2]
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
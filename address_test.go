@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// addressFixture testdata/addresses.jsonの1件。注文の住所と、組み立てた送り状の期待値
+type addressFixture struct {
+	Name string `json:"name"`
+	// Options DefaultConvertOptionsから変える変換オプション。キーはConvertOptionsのフィールド名
+	Options json.RawMessage `json:"options"`
+	// Order 注文データ。キーはShopifyOrderのフィールド名
+	Order ShopifyOrder `json:"order"`
+	// Label 送り状の項目の期待値。キーはClickpostShippingLabelのフィールド名で、書いた項目だけを比べる
+	Label map[string]string `json:"label"`
+	// Error 検証エラーのコード。空の場合は検証に通る
+	Error string `json:"error"`
+}
+
+// TestAddressFixtures 扱いにくい住所の組み立てと検証を、testdata/addresses.jsonの期待値と比べる
+// 住所の組み立ての仕様の一覧を兼ねる。新しい住所の例はファイルの末尾に足していく
+func TestAddressFixtures(t *testing.T) {
+	b, err := os.ReadFile("testdata/addresses.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []addressFixture
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fixtures {
+		t.Run(f.Name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			if len(f.Options) > 0 {
+				if err := json.Unmarshal(f.Options, &opts); err != nil {
+					t.Fatalf("optionsを読めません: %v", err)
+				}
+			}
+			l := f.Order.ToClickpostShippingLabel(opts)
+			v := reflect.ValueOf(l).Elem()
+			for field, want := range f.Label {
+				got := v.FieldByName(field)
+				if !got.IsValid() {
+					t.Fatalf("送り状に%sのフィールドがありません", field)
+				}
+				if got.String() != want {
+					t.Errorf("%s = %q、%qを期待", field, got.String(), want)
+				}
+			}
+			err := l.Validate()
+			var ve *ValidationError
+			switch {
+			case f.Error == "" && err != nil:
+				t.Errorf("Validate() = %v、nilを期待", err)
+			case f.Error != "" && !errors.As(err, &ve):
+				t.Errorf("Validate() = %v、%sを期待", err, f.Error)
+			case f.Error != "" && ve.Code != f.Error:
+				t.Errorf("Code = %q、%qを期待", ve.Code, f.Error)
+			}
+		})
+	}
+}
+
+// TestComposeAddressLinesMatchesLabel 送り状の住所はComposeAddressLinesの行と同じで、注文データは書き換えない
+func TestComposeAddressLinesMatchesLabel(t *testing.T) {
+	opts := DefaultConvertOptions()
+	opts.ArabicNumerals, opts.NormalizeRoom, opts.SplitBuilding = true, true, true
+	o := &ShopifyOrder{
+		ShippingProvince: "東京都",
+		ShippingCity:     "渋谷区",
+		ShippingStreet:   "神南",
+		ShippingAddress1: "一丁目2-3 渋谷マンション",
+		ShippingAddress2: "Room 301",
+	}
+	before := *o
+	lines := ComposeAddressLines(o, opts)
+	if *o != before {
+		t.Errorf("注文データが書き換えられました: %+v", *o)
+	}
+	l := o.ToClickpostShippingLabel(opts)
+	if got := [4]string{l.ShippingAddress1, l.ShippingAddress2, l.ShippingAddress3, l.ShippingAddress4}; got != lines {
+		t.Errorf("送り状の住所 = %q、%qを期待", got, lines)
+	}
+	if want := [4]string{"東京都渋谷区", "神南1丁目2-3", "渋谷マンション", "301号室"}; lines != want {
+		t.Errorf("ComposeAddressLines = %q、%qを期待", lines, want)
+	}
+}
+
+// TestNormalizeHyphens 郵便番号と電話番号のハイフンに似た文字と全角の数字をASCIIにそろえてから検証する
+func TestNormalizeHyphens(t *testing.T) {
+	for _, hyphen := range []string{"-", "－", "―", "‐", "−", "ー", "—", "–"} {
+		t.Run(hyphen, func(t *testing.T) {
+			o := ShopifyOrder{
+				ShippingName:     "山田太郎",
+				ShippingProvince: "東京都",
+				ShippingCity:     "渋谷区",
+				ShippingStreet:   "神南1-2-3",
+				ShippingZip:      "１５０" + hyphen + "０００１",
+				ShippingPhone:    "０９０" + hyphen + "1234" + hyphen + "5678",
+			}
+			l := o.ToClickpostShippingLabel(DefaultConvertOptions())
+			if l.ShippingZip != "150-0001" {
+				t.Errorf("ShippingZip = %q、150-0001を期待", l.ShippingZip)
+			}
+			if l.ShippingPhone != "090-1234-5678" {
+				t.Errorf("ShippingPhone = %q、090-1234-5678を期待", l.ShippingPhone)
+			}
+			if err := l.Validate(); err != nil {
+				t.Errorf("Validate() = %v、nilを期待", err)
+			}
+		})
+	}
+	opts := DefaultConvertOptions()
+	opts.NormalizeHyphens = false
+	o := ShopifyOrder{ShippingZip: "１５０−０００１"}
+	if got := o.ToClickpostShippingLabel(opts).ShippingZip; got != "１５０−０００１" {
+		t.Errorf("-normalize-hyphens=falseのShippingZip = %q、元の値を期待", got)
+	}
+}
+
+// TestCompactAddress 空の住所の行を詰めるかどうか。デフォルトは詰めて、上の行から順に入れる
+func TestCompactAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		street   string
+		address2 string
+		compact  bool
+		want     [4]string
+	}{
+		{name: "建物名あり", street: "神南1-2-3", address2: "渋谷マンション", compact: true, want: [4]string{"東京都渋谷区", "神南1-2-3", "渋谷マンション", ""}},
+		{name: "建物名なし", street: "神南1-2-3", compact: true, want: [4]string{"東京都渋谷区", "神南1-2-3", "", ""}},
+		{name: "町名が空で詰める", address2: "渋谷マンション", compact: true, want: [4]string{"東京都渋谷区", "渋谷マンション", "", ""}},
+		{name: "町名が空で詰めない", address2: "渋谷マンション", want: [4]string{"東京都渋谷区", "", "渋谷マンション", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			opts.CompactAddress = tt.compact
+			o := &ShopifyOrder{ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: tt.street, ShippingAddress2: tt.address2}
+			if got := ComposeAddressLines(o, opts); got != tt.want {
+				t.Errorf("住所 = %q、%qを期待", got, tt.want)
+			}
+		})
+	}
+	if !DefaultConvertOptions().CompactAddress {
+		t.Error("デフォルトで空の行を詰めません")
+	}
+}
+
+// TestSplitFreeTextAddress 1つの文字列の住所を、行の上限までの最後の住所の区切りで分ける
+func TestSplitFreeTextAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		maxLens []int
+		want    []string
+	}{
+		{name: "1行に収まる", address: "東京都渋谷区神南1-2-3", maxLens: []int{20, 20, 20, 20}, want: []string{"東京都渋谷区神南1-2-3"}},
+		{name: "区の後ろで分ける", address: "大阪府大阪市北区梅田三丁目一番一号グランフロント大阪タワーA三十階", maxLens: []int{20, 20, 20, 20}, want: []string{"大阪府大阪市北区", "梅田三丁目一番一号グランフロント大阪タワ", "ーA三十階"}},
+		{name: "区切りがなければ上限で分ける", address: "ＡＢＣＤＥＦＧＨＩＪＫＬＭＮＯＰＱＲＳＴＵＶＷＸＹ", maxLens: []int{10, 10, 10, 10}, want: []string{"ＡＢＣＤＥＦＧＨＩＪ", "ＫＬＭＮＯＰＱＲＳＴ", "ＵＶＷＸＹ"}},
+		{name: "全角と半角は同じ1文字", address: "ABCDEFGHIJＫＬＭＮＯ", maxLens: []int{10, 10}, want: []string{"ABCDEFGHIJ", "ＫＬＭＮＯ"}},
+		{name: "収まらない分は最後の行", address: "東京都 渋谷区 神南1-2-3 渋谷マンション301", maxLens: []int{5, 5}, want: []string{"東京都", "渋谷区 神南1-2-3 渋谷マンション301"}},
+		{name: "上限が0の行に残りをすべて入れる", address: "東京都渋谷区神南1-2-3 渋谷マンション301", maxLens: []int{6, 0, 20}, want: []string{"東京都渋谷区", "神南1-2-3 渋谷マンション301"}},
+		{name: "空欄", address: "  ", maxLens: []int{20, 20}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitFreeTextAddress(tt.address, tt.maxLens); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitFreeTextAddress(%q) = %q、%qを期待", tt.address, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// PlanAppend 出力済みの送り状発行用CSVに配送業者の上限まで入る注文と、入りきらない注文に分ける
+// ファイルは読むだけで書き換えないので、出力ファイル数などを確かめてからAppendClickpostShippingLabelsで追記する
+// 入りきらなかった注文は、入力の順序のまま返すので、通常どおり新しいファイルに分割する
+func PlanAppend(filename string, orders []*ShopifyOrder, eopts ExportOptions) ([]*ShopifyOrder, []*ShopifyOrder, error) {
+	records, _, err := readAppendTarget(filename, eopts)
+	if err != nil {
+		return nil, nil, err
+	}
+	room := eopts.carrier().MaxLabels - (len(records) - 1)
+	var head []*ShopifyOrder
+	for len(orders) > 0 && orders[0].LabelCount() <= room {
+		room -= orders[0].LabelCount()
+		head, orders = append(head, orders[0]), orders[1:]
+	}
+	return head, orders, nil
+}
+
+// AppendClickpostShippingLabels 出力済みの送り状発行用CSVに注文の送り状を追記し、追記した送り状、スキップした注文、ファイルの送り状の件数を返す
+// 注文はPlanAppendで入りきると確かめたものを渡す
+// 既存の行は読み込んだ値のまま書き直し、その後ろに新しい送り状の行を足す
+func AppendClickpostShippingLabels(filename string, orders []*ShopifyOrder, opts ConvertOptions, eopts ExportOptions) ([]*ClickpostShippingLabel, []*RejectedOrder, int, error) {
+	records, t, err := readAppendTarget(filename, eopts)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	labels, rejects := BuildClickpostShippingLabels(orders, opts)
+	if len(labels) == 0 {
+		return nil, rejects, len(records) - 1, nil
+	}
+	records = append(records, t.Records(labels)[1:]...)
+	if err := writeFileAtomic(filename, func(w io.Writer) error { return encodeRecords(w, records, eopts) }); err != nil {
+		return nil, rejects, 0, err
+	}
+	return labels, rejects, len(records) - 1, nil
+}
+
+// readAppendTarget 追記先のファイルを読み込み、今の列の定義とともに返す
+// ヘッダー行が今の列の定義（-carrier-templateなどの指定がなければクリックポストの列）と一致しない場合はエラーにする
+func readAppendTarget(filename string, eopts ExportOptions) ([][]string, *CarrierTemplate, error) {
+	t := eopts.Template
+	if t == nil {
+		var err error
+		if t, err = ClickpostTemplate(nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	records, err := readExportRecords(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expected := t.Records(nil)[0]; !reflect.DeepEqual(records[0], expected) {
+		return nil, nil, fmt.Errorf("%sのヘッダー行が送り状の列（%s）と一致しないため追記できません: %s", filename, strings.Join(expected, ","), strings.Join(records[0], ","))
+	}
+	return records, t, nil
+}
+
+// nextFreeChunkIndex ファイルが存在しない最初のチャンクの番号
+// 追記で入りきらなかった注文を、既存のファイルを上書きせずに新しいファイルへ書き込むために使う
+func nextFreeChunkIndex(filenameFormat string) int {
+	for i := 0; ; i++ {
+		if _, err := os.Stat(fmt.Sprintf(filenameFormat, i)); os.IsNotExist(err) {
+			return i
+		}
+	}
+}
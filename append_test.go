@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// writeAppendTarget n件の注文を書き出したクリックポストのファイルをfilenameに用意する
+func writeAppendTarget(t *testing.T, filename string, n int) {
+	t.Helper()
+	writeOrdersCSV(t, "existing-orders.csv", n, "")
+	in = stringsFlag{"existing-orders.csv"}
+	t.Cleanup(func() { in = nil })
+	captureLog(t)
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename("clickpost-shipping-labels-0.csv", filename); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunAppendChecksMaxFilesFirst 出力ファイル数が上限を超える場合は、追記先のファイルを書き換えずに終了する
+func TestRunAppendChecksMaxFilesFirst(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeAppendTarget(t, "existing.csv", 10)
+	before, err := os.ReadFile("existing.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 30件を追記し、残りの90件は3つのファイルになるので-max-files 2を超える
+	writeOrdersCSV(t, "orders.csv", 120, "")
+	in = stringsFlag{"orders.csv"}
+	setFlags(t, map[string]string{"append": "existing.csv", "max-files": "2"})
+	err = run()
+	if err == nil || !strings.Contains(err.Error(), "出力ファイル数が上限を超えています") {
+		t.Fatalf("runのエラー = %v、出力ファイル数のエラーを期待", err)
+	}
+	after, err := os.ReadFile("existing.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("エラーで終了したのに追記先のファイルが書き換えられています")
+	}
+	if _, err := os.Stat("clickpost-shipping-labels-0.csv"); !os.IsNotExist(err) {
+		t.Errorf("エラーで終了したのに新しいファイルがあります: %v", err)
+	}
+}
+
+// TestRunAppendReportsRejects 追記する注文のうち送り状にできないものは、スキップした注文として報告する
+func TestRunAppendReportsRejects(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeAppendTarget(t, "existing.csv", 10)
+
+	writeOrdersCSV(t, "orders.csv", 5, "#3")
+	in = stringsFlag{"orders.csv"}
+	setFlags(t, map[string]string{"append": "existing.csv", "keep-placeholders": "true"})
+	logs := captureLog(t)
+	err := run()
+	if !errors.Is(err, ErrOrdersSkipped) {
+		t.Fatalf("runのエラー = %v、ErrOrdersSkippedを期待", err)
+	}
+	if !strings.Contains(logs.String(), "注文番号:#3 エラー:お届け先郵便番号は必須です") {
+		t.Errorf("追記でスキップした注文がログにありません:\n%s", logs)
+	}
+	_, labels, err := ReadClickpostShippingLabels("existing.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 15 {
+		t.Errorf("existing.csvの送り状 = %d件、15件を期待", len(labels))
+	}
+}
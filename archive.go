@@ -0,0 +1,54 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+)
+
+// ExportChunksZip チャンクごとの送り状を1つのzipファイルにまとめてエクスポートする
+// zipの各ファイルはチャンクごとのファイルと同じ名前・文字コードで書き込む。送り状が1件もない場合はzipファイルを作らない
+func ExportChunksZip(archive string, chunks [][]*ShopifyOrder, filenameFormat string, opts ConvertOptions, eopts ExportOptions) ([]*ChunkResult, error) {
+	if err := checkChunkFilenames(filenameFormat, len(chunks)); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	var results []*ChunkResult
+	var entries int
+	modified := now()
+	for i, chunkedOrders := range chunks {
+		result := &ChunkResult{Filename: fmt.Sprintf(filenameFormat, i)}
+		results = append(results, result)
+		labels, rejects := BuildClickpostShippingLabels(chunkedOrders, opts)
+		result.Rejects = rejects
+		if len(labels) == 0 {
+			continue
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: result.Filename, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			result.Err = err
+			return results, nil
+		}
+		if err := encodeLabels(w, labels, eopts); err != nil {
+			result.Err = err
+			return results, nil
+		}
+		result.Labels, result.Rows = labels, len(labels)
+		entries++
+	}
+	if err := zw.Close(); err != nil {
+		return results, err
+	}
+	if err := f.Close(); err != nil {
+		return results, err
+	}
+	if entries == 0 {
+		return results, os.Remove(archive)
+	}
+	return results, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 構造化ログのレベル
+const (
+	logLevelInfo  = "info"
+	logLevelWarn  = "warn"
+	logLevelError = "error"
+)
+
+// AuditEntry 構造化ログの1行
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Order  string    `json:"order,omitempty"` // 注文番号。注文ごとの出来事の場合だけ
+	Event  string    `json:"event"`           // start、import、correction、skip、write、endなど
+	Detail string    `json:"detail,omitempty"`
+}
+
+// AuditLog -log-fileの構造化ログ。1行に1つのJSONで書き込む
+// コンソールの出力は消えてしまうので、バッチごとに何を読み込み、何をスキップし、どのファイルを書いたかを残すために使う
+// nilの場合は何も書き込まないので、-log-fileを指定しない場合も呼び出し側で確かめなくてよい
+type AuditLog struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// auditLog 実行中の構造化ログ。-log-fileを指定しない場合はnil
+var auditLog *AuditLog
+
+// OpenAuditLog 構造化ログのファイルを開く。ファイルがある場合は後ろに書き足す
+func OpenAuditLog(filename string) (*AuditLog, error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("ログファイルを開けません: %w", err)
+	}
+	return &AuditLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Log 構造化ログに1行書き込む。書き込みに失敗しても処理は止めず、注意を表示する
+func (l *AuditLog) Log(level, order, event, detail string) {
+	if l == nil {
+		return
+	}
+	entry := AuditEntry{Time: now(), Level: level, Order: order, Event: event, Detail: strings.TrimSpace(detail)}
+	if err := l.enc.Encode(entry); err != nil {
+		warnf("ログファイルに書き込めません: %s\n", err)
+	}
+}
+
+// Finish 実行の結果を書き込んでファイルを閉じる
+func (l *AuditLog) Finish(err error) {
+	if l == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		l.Log(logLevelInfo, "", "end", "")
+	case errors.Is(err, ErrOrdersSkipped):
+		l.Log(logLevelWarn, "", "end", err.Error())
+	default:
+		l.Log(logLevelError, "", "end", err.Error())
+	}
+	if err := l.f.Close(); err != nil {
+		warnf("ログファイルを閉じられません: %s\n", err)
+	}
+}
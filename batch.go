@@ -0,0 +1,30 @@
+package main
+
+// BatchedClickpostShippingLabel バッチ番号の列を先頭に付けた送り状
+type BatchedClickpostShippingLabel struct {
+	Batch int `csv:"バッチ"` // どのアップロード単位（チャンク）に属するか。0始まり
+	ClickpostShippingLabel
+}
+
+// ExportBatchedClickpostShippingLabels チャンクに分けた注文データを1つのファイルにまとめてエクスポート
+// 各行の先頭にチャンクの番号をバッチ番号として出力する。エクスポートした送り状とスキップした注文を返す
+func ExportBatchedClickpostShippingLabels(filename string, chunks [][]*ShopifyOrder, opts ConvertOptions, eopts ExportOptions) ([]*ClickpostShippingLabel, []*RejectedOrder, error) {
+	var rows []*BatchedClickpostShippingLabel
+	var exported []*ClickpostShippingLabel
+	var rejects []*RejectedOrder
+	for i, chunkedOrders := range chunks {
+		labels, r := BuildClickpostShippingLabels(chunkedOrders, opts)
+		rejects = append(rejects, r...)
+		for _, label := range labels {
+			rows = append(rows, &BatchedClickpostShippingLabel{Batch: i, ClickpostShippingLabel: *label})
+		}
+		exported = append(exported, labels...)
+	}
+	if len(rows) == 0 {
+		return nil, rejects, nil
+	}
+	if err := writeCSV(filename, &rows, eopts); err != nil {
+		return nil, rejects, err
+	}
+	return exported, rejects, nil
+}
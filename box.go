@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxBoxCount 1注文あたりの箱数（送り状の枚数）の上限
+const maxBoxCount = 10
+
+// ParseBoxCount 箱数を返す。空欄の場合は1箱
+func (s ShopifyOrder) ParseBoxCount() (int, error) {
+	v := strings.TrimSpace(s.BoxCount)
+	if v == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidBoxCount, s.BoxCount)
+	}
+	if n > maxBoxCount {
+		return 0, fmt.Errorf("%w（%d箱まで）: %d", ErrTooManyBoxes, maxBoxCount, n)
+	}
+	return n, nil
+}
+
+// LabelCount 注文が消費する送り状の枚数
+// 箱数が不正な注文はエクスポート時にスキップされるが、分割では1枚として数える
+func (s ShopifyOrder) LabelCount() int {
+	n, err := s.ParseBoxCount()
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+// ToClickpostShippingLabels 箱数分の送り状に変換
+// 2箱以上の場合は個口番号（1/3, 2/3, 3/3）を付ける
+func (s ShopifyOrder) ToClickpostShippingLabels(opts ConvertOptions) ([]*ClickpostShippingLabel, error) {
+	n, err := s.ParseBoxCount()
+	if err != nil {
+		return nil, err
+	}
+	// 電話番号や注文番号を氏名の欄に入力してしまう誤りは、文字数の検証では見つからない
+	if opts.RequireNameLetters && s.ShippingName != "" && strings.IndexFunc(s.ShippingName, unicode.IsLetter) < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNameNoLetters, s.ShippingName)
+	}
+	if opts.RejectSpecialAddress {
+		if m := opts.specialAddressMarker(s); m != "" {
+			return nil, fmt.Errorf("%w: %s", ErrSpecialAddress, m)
+		}
+	}
+	if opts.ContentsTemplate != nil {
+		if _, err := opts.templateContentsItems(s); err != nil {
+			return nil, err
+		}
+	}
+	// 変換は1回だけにして、箱ごとの送り状はそのコピーにする。トレースやフックも1注文に1回だけ呼ばれる
+	base := s.ToClickpostShippingLabel(opts)
+	// 住所の組み立てで同じ町名や番地が複数の行に入ると、各行は上限以内でも全体として長すぎる住所になる
+	if opts.MaxAddressTotal > 0 && addressTotalLength(base) > opts.MaxAddressTotal {
+		return nil, fmt.Errorf("%w（%d文字まで）", ErrAddressTotalTooLong.withValue(addressText(base)), opts.MaxAddressTotal)
+	}
+	var groups [][]string
+	if opts.SplitContents && n > 1 {
+		items, _ := opts.orderContentsItems(s)
+		groups = splitContentsItems(items, opts.carrier().maxLen("ShippingContents"), n)
+	}
+	labels := make([]*ClickpostShippingLabel, 0, n)
+	for i := 1; i <= n; i++ {
+		copied := *base
+		copied.ContentsItems = slices.Clone(base.ContentsItems)
+		label := &copied
+		if n > 1 {
+			label.ShippingPiece = fmt.Sprintf("%d/%d", i, n)
+		}
+		if len(groups) > 0 {
+			items := groups[min(i, len(groups))-1]
+			label.ContentsItems, label.ShippingContents = items, JoinClickpostContents(items)
+			opts.trace("ShippingContents", "%d箱目の送り状に品目を分ける（-split-contents）: %s", i, label.ShippingContents)
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// addressTotalLength 送り状の住所1〜4行目の合計の文字数
+func addressTotalLength(l *ClickpostShippingLabel) int {
+	return utf8.RuneCountInString(addressText(l))
+}
+
+// addressText 送り状の住所1〜4行目をつないだ文字列
+func addressText(l *ClickpostShippingLabel) string {
+	return l.ShippingAddress1 + l.ShippingAddress2 + l.ShippingAddress3 + l.ShippingAddress4
+}
+
+// splitContentsItems 内容品の品目を、つないだ長さがmaxLen以内のまとまりに先頭から分ける
+// 1つにまとめて収まる場合、boxes個より多く分かれる場合、1品目でmaxLenを超える場合は分けずにnilを返す
+// 箱数がまとまりより多い場合、残りの箱は最後のまとまりと同じ内容品にする
+func splitContentsItems(items []string, maxLen, boxes int) [][]string {
+	if maxLen <= 0 || utf8.RuneCountInString(JoinClickpostContents(items)) <= maxLen {
+		return nil
+	}
+	var groups [][]string
+	var group []string
+	for _, item := range items {
+		if utf8.RuneCountInString(item) > maxLen {
+			return nil
+		}
+		if len(group) > 0 && utf8.RuneCountInString(JoinClickpostContents(append(group, item))) > maxLen {
+			groups, group = append(groups, group), nil
+		}
+		group = append(group, item)
+	}
+	groups = append(groups, group)
+	if len(groups) > boxes {
+		return nil
+	}
+	return groups
+}
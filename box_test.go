@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+// TestToClickpostShippingLabelsConvertsOnce 複数の箱の注文でも、変換のフックは1回だけ呼ばれ、箱ごとの送り状は別のものになる
+func TestToClickpostShippingLabelsConvertsOnce(t *testing.T) {
+	calls := 0
+	opts := DefaultConvertOptions()
+	opts.MaxAddressTotal = 80
+	opts.Hooks = []LabelHook{func(o *ShopifyOrder, l *ClickpostShippingLabel) { calls++ }}
+	o := ShopifyOrder{
+		Name:             "#1001",
+		ShippingName:     "山田太郎",
+		ShippingStreet:   "神南1-2-3",
+		ShippingCity:     "渋谷区",
+		ShippingZip:      "150-0041",
+		ShippingProvince: "東京都",
+		BoxCount:         "3",
+	}
+	labels, err := o.ToClickpostShippingLabels(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("フックの呼び出し = %d回、1回を期待", calls)
+	}
+	if len(labels) != 3 {
+		t.Fatalf("送り状 = %d枚、3枚を期待", len(labels))
+	}
+	for i, want := range []string{"1/3", "2/3", "3/3"} {
+		if labels[i].ShippingPiece != want {
+			t.Errorf("%d枚目の個口番号 = %q、%qを期待", i+1, labels[i].ShippingPiece, want)
+		}
+		if labels[i].ShippingName != "山田太郎" {
+			t.Errorf("%d枚目の氏名 = %q、山田太郎を期待", i+1, labels[i].ShippingName)
+		}
+	}
+}
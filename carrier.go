@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldRule 送り状の項目ごとの検証ルール
+type FieldRule struct {
+	Field    string // ClickpostShippingLabelのフィールド名
+	Code     string // エラーコードの接頭辞
+	Label    string // メッセージに表示する項目名
+	Required bool   // 必須かどうか
+	MaxLen   int    // 全角での最大文字数。0は無制限
+	// Number 数値項目の書式。nilの場合は文字列の項目
+	Number *NumberFormat
+	// Phone 電話番号の項目。日本の電話番号として読めるか検証する
+	Phone bool
+	// LenientLength 文字数の上限を超えてもエラーにしない。-lenient-lengthで指定し、超えた項目は警告で表示する
+	LenientLength bool
+}
+
+// requiredError 必須エラー
+func (r FieldRule) requiredError() *ValidationError {
+	return &ValidationError{Code: r.Code + "_required", Message: r.Label + "は必須です"}
+}
+
+// controlCharError 制御文字エラー
+func (r FieldRule) controlCharError() *ValidationError {
+	return &ValidationError{Code: r.Code + "_control_char", Message: r.Label + "に改行やタブなどの制御文字が含まれています"}
+}
+
+// tooLongError 文字数超過エラー。上限を超えた値と文字数を添える
+func (r FieldRule) tooLongError(value string) *ValidationError {
+	e := &ValidationError{Code: r.Code + "_too_long", Message: fmt.Sprintf("%sは全角%d文字までです", r.Label, r.MaxLen), MaxLen: r.MaxLen}
+	return e.withValue(value)
+}
+
+// Carrier 配送業者の定義
+// 配送業者を追加する場合は、Validateを書く代わりに項目ごとの必須・文字数のルールを宣言する
+type Carrier struct {
+	Name      string      // 配送業者名
+	MaxLabels int         // 1ファイルにアップロードできる送り状の上限
+	Fields    []FieldRule // 項目ごとの検証ルール。この順に検証する
+	// ExtraRules 項目ごとの検証ルールの後に検証する、ストアごとの追加のルール。AddRuleで登録する
+	ExtraRules []LabelRule
+	// PhoneFormat 電話番号の書式。空の場合はハイフン区切り
+	PhoneFormat PhoneFormat
+	// Encoding アップロードするCSVの文字コード。空の場合はShift-JIS。-encodingを指定した場合はそちらを使う
+	Encoding Encoding
+	// LineEnding アップロードするCSVの改行コード。空の場合はCRLF。-line-endingを指定した場合はそちらを使う
+	LineEnding LineEnding
+	// NameTruncationMarker -truncate-nameで切り詰めた氏名の末尾に付ける目印。空の場合は「…」
+	NameTruncationMarker string
+}
+
+// exportOptions 配送業者の文字コードと改行コードの書き込みオプション。encodingとlineEndingが空でなければそちらを使う
+func (c *Carrier) exportOptions(encoding, lineEnding string) (ExportOptions, error) {
+	eopts := ExportOptions{LineEnding: c.LineEnding, Encoding: c.Encoding}
+	var err error
+	if lineEnding != "" {
+		if eopts.LineEnding, err = ParseLineEnding(lineEnding); err != nil {
+			return ExportOptions{}, err
+		}
+	}
+	if encoding != "" {
+		if eopts.Encoding, err = ParseEncoding(encoding); err != nil {
+			return ExportOptions{}, err
+		}
+	}
+	return eopts, nil
+}
+
+// formatPhone 電話番号を配送業者の書式にそろえる。読めない場合は検証で報告できるよう元の値のまま返す
+func (c *Carrier) formatPhone(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	normalized, err := NormalizePhone(s, c.PhoneFormat)
+	if err != nil {
+		return s
+	}
+	return normalized
+}
+
+// clickpostCarrierName 組み込みのクリックポストの配送業者名。設定ファイルに定義がなくても-carrierで選べる
+const clickpostCarrierName = "clickpost"
+
+// Clickpost クリックポストの組み込みの定義。変更せず、選んだ配送業者や上書きした定義はConvertOptions.Carrierで渡す
+var Clickpost = &Carrier{
+	Name:       clickpostCarrierName,
+	MaxLabels:  maxClickpostShippingLabels,
+	Encoding:   EncodingShiftJIS,
+	LineEnding: LineEndingCRLF,
+	Fields: []FieldRule{
+		{Field: "ShippingZip", Code: "zip", Label: "お届け先郵便番号", Required: true},
+		{Field: "ShippingName", Code: "name", Label: "お届け先氏名", Required: true, MaxLen: 20},
+		{Field: "ShippingAddress1", Code: "address1", Label: "お届け先住所1行目", Required: true, MaxLen: 20},
+		{Field: "ShippingAddress2", Code: "address2", Label: "お届け先住所2行目", Required: true, MaxLen: 20},
+		{Field: "ShippingAddress3", Code: "address3", Label: "お届け先住所3行目", MaxLen: 20},
+		{Field: "ShippingAddress4", Code: "address4", Label: "お届け先住所4行目", MaxLen: 20},
+		{Field: "ShippingContents", Code: "contents", Label: "内容品", Required: true, MaxLen: 15},
+	},
+}
+
+// FormatNumber 数値項目の値を配送業者の書式に整える
+// 変換処理ではstrconvで個別に整形せず、この関数を通して項目ごとの書式を揃える
+func (c *Carrier) FormatNumber(field, value string) (string, error) {
+	for _, r := range c.Fields {
+		if r.Field != field {
+			continue
+		}
+		if r.Number == nil {
+			return "", fmt.Errorf("%sは数値項目ではありません", r.Label)
+		}
+		s, err := r.Number.FormatString(value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", r.Label, err)
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("%sの項目は%sにありません", field, c.Name)
+}
+
+// formatNumber 数値項目の値を配送業者の書式にそろえる
+// 数値項目のルールがない場合は元の値のまま返す。読めない場合も、検証で報告できるよう元の値のまま返す
+func (c *Carrier) formatNumber(field, value string) string {
+	s, err := c.FormatNumber(field, value)
+	if err != nil {
+		return value
+	}
+	return s
+}
+
+// maxLen 項目の全角での最大文字数。ルールがない場合は0
+func (c *Carrier) maxLen(field string) int {
+	for _, r := range c.Fields {
+		if r.Field == field {
+			return r.MaxLen
+		}
+	}
+	return 0
+}
+
+// ruleByCode エラーコードから検証ルールを探す。「address1_too_long」のように接尾辞の付いたコードも受け付ける
+func (c *Carrier) ruleByCode(code string) (FieldRule, bool) {
+	for _, r := range c.Fields {
+		if code == r.Code || strings.HasPrefix(code, r.Code+"_") {
+			return r, true
+		}
+	}
+	return FieldRule{}, false
+}
+
+// Validate 配送業者のルールで送り状を検証し、最初に見つかったエラーを返す
+func (c *Carrier) Validate(l *ClickpostShippingLabel) error {
+	for _, r := range c.Rules() {
+		if err := r.Check(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateAll 配送業者のルールで送り状を検証し、ルールごとに最初に見つかったエラーをすべて返す
+// 手作業で編集したファイルの確認で、1行の誤りをまとめて直せるようにする
+func (c *Carrier) ValidateAll(l *ClickpostShippingLabel) []error {
+	var errs []error
+	for _, r := range c.Rules() {
+		if err := r.Check(l); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validateField 1つの項目の値をルールで検証する
+func (c *Carrier) validateField(r FieldRule, value string) error {
+	if r.Required && value == "" {
+		return r.requiredError()
+	}
+	// 改行が含まれるとCSVの1行が送り状の2行に分かれてしまう
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return r.controlCharError()
+	}
+	if r.Number != nil && value != "" {
+		if _, err := r.Number.ParseNumber(value); err != nil {
+			return &ValidationError{Code: r.Code + "_invalid_number", Message: r.Label + "は" + ErrInvalidNumber.Message}
+		}
+	}
+	if r.Phone && value != "" {
+		if _, err := NormalizePhone(value, c.PhoneFormat); err != nil {
+			return &ValidationError{Code: r.Code + "_invalid", Message: r.Label + "は市外局番から10桁か11桁で指定してください"}
+		}
+	}
+	if r.MaxLen > 0 && !r.LenientLength && utf8.RuneCountInString(value) > r.MaxLen {
+		return r.tooLongError(value)
+	}
+	return nil
+}
+
+// ParseMaxLenOverrides 「name=25,address1=30」の形式の文字数の上限の上書きを読む
+// キーは検証ルールのエラーコードの接頭辞で、上限は1以上の整数で指定する
+func ParseMaxLenOverrides(s string) (map[string]int, error) {
+	overrides := map[string]int{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("文字数の上限は項目=文字数の形式で指定してください: %s", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("文字数の上限は1以上の整数で指定してください: %s", entry)
+		}
+		overrides[strings.TrimSpace(code)] = n
+	}
+	return overrides, nil
+}
+
+// WithMaxLen 項目ごとの文字数の上限を上書きした配送業者の定義を返す。cは変更しない
+// 配送業者の仕様の上限を超える上書きは、アップロードで弾かれる可能性があるので警告を返す
+func (c *Carrier) WithMaxLen(overrides map[string]int) (*Carrier, []string, error) {
+	copied := *c
+	copied.Fields = append([]FieldRule(nil), c.Fields...)
+	var warnings []string
+	for code, n := range overrides {
+		i := slices.IndexFunc(copied.Fields, func(r FieldRule) bool { return r.Code == code })
+		if i < 0 {
+			return nil, nil, fmt.Errorf("%sに%sの項目はありません", c.Name, code)
+		}
+		r := &copied.Fields[i]
+		if r.MaxLen > 0 && n > r.MaxLen {
+			warnings = append(warnings, fmt.Sprintf("%sの上限%d文字は%sの仕様（%d文字）を超えています", r.Label, n, c.Name, r.MaxLen))
+		}
+		r.MaxLen = n
+	}
+	sort.Strings(warnings)
+	return &copied, warnings, nil
+}
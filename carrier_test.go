@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCarrierNumberField 設定ファイルのnumberの書式で重さの項目をそろえ、数値として読めない値はエラーにする
+func TestCarrierNumberField(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "carriers.json")
+	const carriers = `{"carriers": {"yupack": {"max_labels": 30, "fields": [
+		{"field": "ShippingZip", "code": "zip", "label": "郵便番号", "required": true},
+		{"field": "ShippingWeight", "code": "weight", "label": "重さ", "number": {"grouping": true, "unit": "g"}}
+	]}}}`
+	if err := os.WriteFile(filename, []byte(carriers), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := LoadCarriers(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultConvertOptions()
+	opts.Carrier = configs["yupack"].Carrier
+	tests := []struct {
+		name   string
+		weight string
+		want   string
+		code   string
+	}{
+		{name: "グラム数", weight: "1500", want: "1,500g"},
+		{name: "全角数字", weight: "１２００", want: "1,200g"},
+		{name: "空欄", weight: "", want: ""},
+		{name: "数値ではない", weight: "重い", want: "重い", code: "weight_invalid_number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &ShopifyOrder{ShippingZip: "150-0041", TotalWeight: tt.weight}
+			l := o.ToClickpostShippingLabel(opts)
+			if l.ShippingWeight != tt.want {
+				t.Errorf("ShippingWeight = %q、%qを期待", l.ShippingWeight, tt.want)
+			}
+			err := opts.Carrier.Validate(l)
+			var ve *ValidationError
+			switch {
+			case tt.code == "" && err != nil:
+				t.Errorf("Validate() = %v、nilを期待", err)
+			case tt.code != "" && (!errors.As(err, &ve) || ve.Code != tt.code):
+				t.Errorf("Validate() = %v、%sを期待", err, tt.code)
+			}
+		})
+	}
+}
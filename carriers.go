@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CarrierConfig 設定ファイルで定義した配送業者。検証ルールと、省略できる送り状のCSVの列の定義
+type CarrierConfig struct {
+	Carrier  *Carrier
+	Template *CarrierTemplate // nilの場合はクリックポストの列で出力する
+}
+
+// carriersDocument 複数の配送業者を定義する設定ファイルの形式
+//
+//	{"carriers": {"yupacket": {"max_labels": 500, "fields": [...], "columns": [...]}}}
+type carriersDocument struct {
+	Carriers map[string]carrierDefinition `json:"carriers"`
+}
+
+// carrierDefinition 設定ファイルの配送業者1件
+type carrierDefinition struct {
+	MaxLabels   int    `json:"max_labels"`   // 1ファイルにアップロードできる送り状の上限
+	PhoneFormat string `json:"phone_format"` // 電話番号の書式。hyphenかplain。省略した場合はhyphen
+	Encoding    string `json:"encoding"`     // 出力するCSVの文字コード。sjis、utf8bom、utf8のいずれか。省略した場合はsjis
+	LineEnding  string `json:"line_ending"`  // 出力するCSVの改行コード。crlfかlf。省略した場合はcrlf
+	// NameTruncationMarker -truncate-nameで切り詰めた氏名の末尾に付ける目印。省略した場合は「…」
+	NameTruncationMarker string           `json:"name_truncation_marker"`
+	Fields               []fieldRuleEntry `json:"fields"` // 項目ごとの検証ルール
+	// Columns 送り状のCSVの列。依頼主の名前や住所のような固定の項目はvalueで指定する。省略した場合はクリックポストの列
+	Columns []TemplateColumn `json:"columns"`
+}
+
+// fieldRuleEntry 設定ファイルの検証ルール1件
+type fieldRuleEntry struct {
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+	MaxLen   int    `json:"max_len"`
+	Phone    bool   `json:"phone"`
+	// Number 数値項目の書式。指定した項目は数値として読めるか検証し、書式にそろえて出力する
+	Number *numberFormatEntry `json:"number"`
+}
+
+// numberFormatEntry 設定ファイルの数値項目の書式
+type numberFormatEntry struct {
+	Grouping bool   `json:"grouping"` // 3桁ごとにカンマで区切る
+	Unit     string `json:"unit"`     // 数値の後ろに付ける単位
+}
+
+// LoadCarriers 複数の配送業者を定義した設定ファイルを読み込む
+// 実行時に使わない配送業者の誤りも見つかるよう、すべての定義を読み込む時に検証する
+func LoadCarriers(filename string) (map[string]*CarrierConfig, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var f carriersDocument
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", filename, err)
+	}
+	if len(f.Carriers) == 0 {
+		return nil, fmt.Errorf("%sに配送業者が定義されていません", filename)
+	}
+	configs := map[string]*CarrierConfig{}
+	for name, d := range f.Carriers {
+		c, err := d.config(name)
+		if err != nil {
+			return nil, fmt.Errorf("%sの%s: %w", filename, name, err)
+		}
+		configs[name] = c
+	}
+	return configs, nil
+}
+
+// config 設定ファイルの定義を検証し、配送業者にする
+func (d carrierDefinition) config(name string) (*CarrierConfig, error) {
+	if d.MaxLabels < 1 {
+		return nil, fmt.Errorf("max_labelsは1以上を指定してください: %d", d.MaxLabels)
+	}
+	phone := PhoneFormatHyphen
+	switch PhoneFormat(d.PhoneFormat) {
+	case "", PhoneFormatHyphen:
+	case PhoneFormatPlain:
+		phone = PhoneFormatPlain
+	default:
+		return nil, fmt.Errorf("phone_formatはhyphenかplainを指定してください: %s", d.PhoneFormat)
+	}
+	if len(d.Fields) == 0 {
+		return nil, errors.New("fieldsが定義されていません")
+	}
+	c := &Carrier{Name: name, MaxLabels: d.MaxLabels, PhoneFormat: phone, Encoding: EncodingShiftJIS, LineEnding: LineEndingCRLF, NameTruncationMarker: d.NameTruncationMarker}
+	var err error
+	if d.Encoding != "" {
+		if c.Encoding, err = ParseEncoding(d.Encoding); err != nil {
+			return nil, err
+		}
+	}
+	if d.LineEnding != "" {
+		if c.LineEnding, err = ParseLineEnding(d.LineEnding); err != nil {
+			return nil, err
+		}
+	}
+	labelType := reflect.TypeOf(ClickpostShippingLabel{})
+	codes := map[string]bool{}
+	for i, e := range d.Fields {
+		if f, ok := labelType.FieldByName(e.Field); !ok || f.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("%d番目のfield「%s」は送り状のフィールドにありません", i+1, e.Field)
+		}
+		if e.Code == "" || e.Label == "" {
+			return nil, fmt.Errorf("%sのcodeとlabelを指定してください", e.Field)
+		}
+		if codes[e.Code] {
+			return nil, fmt.Errorf("code「%s」が重複しています", e.Code)
+		}
+		codes[e.Code] = true
+		if e.MaxLen < 0 {
+			return nil, fmt.Errorf("%sのmax_lenは0以上を指定してください: %d", e.Field, e.MaxLen)
+		}
+		rule := FieldRule{Field: e.Field, Code: e.Code, Label: e.Label, Required: e.Required, MaxLen: e.MaxLen, Phone: e.Phone}
+		if e.Number != nil {
+			rule.Number = &NumberFormat{Grouping: e.Number.Grouping, Unit: e.Number.Unit}
+		}
+		c.Fields = append(c.Fields, rule)
+	}
+	config := &CarrierConfig{Carrier: c}
+	if len(d.Columns) > 0 {
+		config.Template = &CarrierTemplate{Columns: d.Columns}
+		if err := config.Template.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// SelectCarrier 名前で配送業者を選ぶ。clickpostは設定ファイルに定義がなくても選べる
+// 見つからない場合は選べる配送業者の名前を並べたエラーを返す
+func SelectCarrier(configs map[string]*CarrierConfig, name string) (*CarrierConfig, error) {
+	if c, ok := configs[name]; ok {
+		return c, nil
+	}
+	if name == clickpostCarrierName {
+		return &CarrierConfig{Carrier: Clickpost}, nil
+	}
+	names := []string{clickpostCarrierName}
+	for n := range configs {
+		if n != clickpostCarrierName {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("配送業者「%s」は定義されていません。選べる配送業者: %s", name, strings.Join(names, ", "))
+}
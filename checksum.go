@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// OutputFile 書き込んだ送り状のファイルと、そのファイルに含まれる送り状の件数
+type OutputFile struct {
+	Path string
+	Rows int
+}
+
+// ChecksumEntry チェックサムの一覧の1行
+type ChecksumEntry struct {
+	File   string `csv:"ファイル名"`
+	SHA256 string `csv:"SHA-256"`
+	Rows   int    `csv:"行数"`
+}
+
+// fileSHA256 ファイルの内容のSHA-256を16進数の文字列で返す
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksums 書き込んだ送り状のファイルごとのSHA-256と行数の一覧を書き込む
+// アップロードまでにファイルが変わっていないか後で確かめられるよう、すべてのファイルを書き終えてから呼ぶ
+// ファイル名は、-stage-dirから移動しても照合できるようディレクトリを除いて書き込む
+func WriteChecksums(filename string, outputs []OutputFile, eopts ExportOptions) error {
+	entries := make([]*ChecksumEntry, 0, len(outputs))
+	for _, o := range outputs {
+		sum, err := fileSHA256(o.Path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, &ChecksumEntry{File: filepath.Base(o.Path), SHA256: sum, Rows: o.Rows})
+	}
+	return writeCSV(filename, &entries, eopts)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChunkMode 注文データの分割方法
+type ChunkMode string
+
+const (
+	// ChunkModeGreedy 先頭から上限まで詰めて分割する
+	ChunkModeGreedy ChunkMode = "greedy"
+	// ChunkModeBalanced ファイル数を最小にしたまま、各ファイルの件数が均等になるよう分割する
+	ChunkModeBalanced ChunkMode = "balanced"
+)
+
+// ParseChunkMode 文字列から分割方法を返す
+func ParseChunkMode(s string) (ChunkMode, error) {
+	switch mode := ChunkMode(s); mode {
+	case ChunkModeGreedy, ChunkModeBalanced:
+		return mode, nil
+	}
+	return "", fmt.Errorf("分割方法はgreedyかbalancedを指定してください: %s", s)
+}
+
+// ChunkShopifyOrdersBy 分割方法に従って注文データを分割
+// どの分割方法でも、チャンクをつなげると入力と同じ順序になる。梱包では注文一覧と送り状の順序を突き合わせるので、並べ替えてはいけない
+func ChunkShopifyOrdersBy(mode ChunkMode, items []*ShopifyOrder, chunkSize int) [][]*ShopifyOrder {
+	if mode == ChunkModeBalanced {
+		return ChunkShopifyOrdersBalanced(items, chunkSize)
+	}
+	return ChunkShopifyOrders(items, chunkSize)
+}
+
+// ChunkShopifyOrdersBalanced ファイル数を最小にしたまま、送り状の枚数が均等になるよう注文データを分割
+// 41枚を上限40枚で分ける場合、40枚と1枚ではなく21枚と20枚にする。1ファイルが上限を超えることはない
+func ChunkShopifyOrdersBalanced(items []*ShopifyOrder, chunkSize int) [][]*ShopifyOrder {
+	var total int
+	for _, o := range items {
+		total += o.LabelCount()
+	}
+	n := (total + chunkSize - 1) / chunkSize
+	if n <= 1 {
+		return ChunkShopifyOrders(items, chunkSize)
+	}
+	chunks := ChunkShopifyOrders(items, (total+n-1)/n)
+	// 複数箱の注文があると均等な枠に収まらないことがあるので、その場合は通常の分割にする
+	if len(chunks) > n {
+		return ChunkShopifyOrders(items, chunkSize)
+	}
+	return chunks
+}
+
+// ParseChunkRange 「3」または「2-4」の形式のチャンクの番号の範囲を読む
+// 番号は出力ファイル名の番号と同じで0から始まる。n個のチャンクの範囲外の番号はエラーにする
+func ParseChunkRange(s string, n int) (from, to int, err error) {
+	first, last, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	if from, err = strconv.Atoi(strings.TrimSpace(first)); err != nil {
+		return 0, 0, fmt.Errorf("チャンクの範囲は「3」か「2-4」の形式で指定してください: %s", s)
+	}
+	to = from
+	if isRange {
+		if to, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+			return 0, 0, fmt.Errorf("チャンクの範囲は「3」か「2-4」の形式で指定してください: %s", s)
+		}
+	}
+	if from < 0 || from > to {
+		return 0, 0, fmt.Errorf("チャンクの範囲が不正です: %s", s)
+	}
+	if to >= n {
+		return 0, 0, fmt.Errorf("チャンクの範囲が範囲外です。チャンクは0〜%dの%d件です: %s", n-1, n, s)
+	}
+	return from, to, nil
+}
+
+// SortByPrintOrder 「Print Order」列の番号順に注文を並べ替える。分割の前に呼ぶ
+// 番号が空欄や整数として読めない注文は後ろに回し、同じ番号の注文や番号のない注文どうしは入力の順序を保つ
+// 列がない入力ではすべての注文に番号がないので、入力の順序のままになる
+func SortByPrintOrder(orders []*ShopifyOrder) {
+	key := func(o *ShopifyOrder) (int, bool) {
+		n, err := strconv.Atoi(strings.TrimSpace(o.PrintOrder))
+		return n, err == nil
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		a, okA := key(orders[i])
+		b, okB := key(orders[j])
+		if okA != okB {
+			return okA
+		}
+		return okA && a < b
+	})
+}
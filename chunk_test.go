@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testOrders n件の注文。boxesが0でない注文はその箱数にする
+func testOrders(n int, boxes map[int]int) []*ShopifyOrder {
+	orders := make([]*ShopifyOrder, n)
+	for i := range orders {
+		o := &ShopifyOrder{Name: fmt.Sprintf("#%d", i+1)}
+		if b := boxes[i]; b > 0 {
+			o.BoxCount = fmt.Sprint(b)
+		}
+		orders[i] = o
+	}
+	return orders
+}
+
+// chunkLabelCount チャンクの注文が消費する送り状の枚数
+func chunkLabelCount(chunk []*ShopifyOrder) int {
+	n := 0
+	for _, o := range chunk {
+		n += o.LabelCount()
+	}
+	return n
+}
+
+// TestChunkPreservesOrder どの分割方法でも、チャンクをつなげると入力と同じ順序になる
+func TestChunkPreservesOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		boxes map[int]int
+	}{
+		{name: "上限ちょうど", n: 40},
+		{name: "上限を1件超える", n: 41},
+		{name: "3ファイル", n: 85},
+		{name: "複数箱の注文を含む", n: 70, boxes: map[int]int{3: 2, 38: 3, 39: 5, 60: 10}},
+	}
+	for _, mode := range []ChunkMode{ChunkModeGreedy, ChunkModeBalanced} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%s", mode, tt.name), func(t *testing.T) {
+				orders := testOrders(tt.n, tt.boxes)
+				chunks := ChunkShopifyOrdersBy(mode, orders, 40)
+				var joined []*ShopifyOrder
+				for i, chunk := range chunks {
+					if n := chunkLabelCount(chunk); n > 40 {
+						t.Errorf("%d番目のチャンクの送り状 = %d枚、40枚以下を期待", i, n)
+					}
+					joined = append(joined, chunk...)
+				}
+				if len(joined) != len(orders) {
+					t.Fatalf("チャンクをつなげた注文 = %d件、%d件を期待", len(joined), len(orders))
+				}
+				for i := range orders {
+					if joined[i] != orders[i] {
+						t.Fatalf("%d番目の注文 = %s、%sを期待", i, joined[i].Name, orders[i].Name)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestInvalidOrdersDoNotUseChunkSlots 送り状にできない注文を分割の前に除くので、各ファイルに検証に通った送り状が上限まで入る
+func TestInvalidOrdersDoNotUseChunkSlots(t *testing.T) {
+	// 100件のうち5件に1件は郵便番号が空欄で、検証に通る80件が40枚ずつの2ファイルになる
+	var orders []*ShopifyOrder
+	for i := 1; i <= 100; i++ {
+		o := &ShopifyOrder{Name: fmt.Sprintf("#%d", i), ShippingName: "山田太郎", ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "神南1-2-3", ShippingZip: "150-0041"}
+		if i%5 == 0 {
+			o.ShippingZip = ""
+		}
+		orders = append(orders, o)
+	}
+	opts := DefaultConvertOptions()
+	valid, rejects := SelectValidOrders(orders, opts)
+	if len(rejects) != 20 {
+		t.Errorf("スキップした注文 = %d件、20件を期待", len(rejects))
+	}
+	chunks := ChunkShopifyOrdersBy(ChunkModeGreedy, valid, 40)
+	if len(chunks) != 2 {
+		t.Fatalf("チャンク = %d件、2件を期待", len(chunks))
+	}
+	for i, chunk := range chunks {
+		labels, r := BuildClickpostShippingLabels(chunk, opts)
+		if len(r) > 0 {
+			t.Errorf("%d番目のチャンクでスキップした注文 = %d件、0件を期待", i, len(r))
+		}
+		if len(labels) != 40 {
+			t.Errorf("%d番目のチャンクの送り状 = %d枚、40枚を期待", i, len(labels))
+		}
+	}
+}
+
+// TestMultiBoxOrderSpillsToNextChunk 上限の手前にある3箱の注文は、今のファイルをあふれさせずに次のファイルへ回る
+func TestMultiBoxOrderSpillsToNextChunk(t *testing.T) {
+	tests := []struct {
+		name   string
+		before int   // 3箱の注文の前にある1箱の注文の件数
+		want   []int // チャンクごとの送り状の枚数
+	}{
+		{name: "残り1枠", before: 39, want: []int{39, 3 + 20}},
+		{name: "残り2枠", before: 38, want: []int{38, 3 + 20}},
+		{name: "残り3枠", before: 37, want: []int{40, 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := testOrders(tt.before+1+20, map[int]int{tt.before: 3})
+			chunks := ChunkShopifyOrders(orders, 40)
+			got := make([]int, len(chunks))
+			for i, chunk := range chunks {
+				got[i] = chunkLabelCount(chunk)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("チャンクごとの送り状 = %v枚、%v枚を期待", got, tt.want)
+			}
+			// 3箱の注文の送り状は1つのファイルにまとまる
+			if tt.before+3 > 40 && chunks[1][0] != orders[tt.before] {
+				t.Errorf("2番目のチャンクの先頭 = %s、3箱の注文%sを期待", chunks[1][0].Name, orders[tt.before].Name)
+			}
+		})
+	}
+}
+
+// TestChunkBalanced balancedの分割は、最少のファイル数に注文を均等に分ける
+func TestChunkBalanced(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []int
+	}{
+		{n: 41, want: []int{21, 20}},
+		{n: 80, want: []int{40, 40}},
+		{n: 81, want: []int{27, 27, 27}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d件", tt.n), func(t *testing.T) {
+			chunks := ChunkShopifyOrdersBy(ChunkModeBalanced, testOrders(tt.n, nil), 40)
+			got := make([]int, len(chunks))
+			for i, chunk := range chunks {
+				got[i] = chunkLabelCount(chunk)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("チャンクごとの送り状 = %v枚、%v枚を期待", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSortByPrintOrder Print Orderの番号順に並べ、番号の重複や欠番、番号のない注文でも入力の順序を保つ
+func TestSortByPrintOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		print []string // 注文#1から順のPrint Order
+		want  []string
+	}{
+		{name: "列なし", print: []string{"", "", ""}, want: []string{"#1", "#2", "#3"}},
+		{name: "番号順", print: []string{"3", "1", "2"}, want: []string{"#2", "#3", "#1"}},
+		{name: "欠番", print: []string{"10", "2", "7"}, want: []string{"#2", "#3", "#1"}},
+		{name: "重複", print: []string{"2", "1", "2", "1"}, want: []string{"#2", "#4", "#1", "#3"}},
+		{name: "空欄と読めない値は後ろ", print: []string{"", "2", "abc", "1", ""}, want: []string{"#4", "#2", "#1", "#3", "#5"}},
+		{name: "前後の空白", print: []string{" 2", "1 "}, want: []string{"#2", "#1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := testOrders(len(tt.print), nil)
+			for i, p := range tt.print {
+				orders[i].PrintOrder = p
+			}
+			SortByPrintOrder(orders)
+			got := make([]string, len(orders))
+			for i, o := range orders {
+				got[i] = o.Name
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("並べ替えた注文 = %v、%vを期待", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+)
+
+// shopifyOrderColumns ShopifyOrderのcsvタグから、読み込む列名を返す
+func shopifyOrderColumns() []string {
+	var columns []string
+	t := reflect.TypeOf(ShopifyOrder{})
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("csv"); tag != "" && tag != "-" {
+			columns = append(columns, tag)
+		}
+	}
+	return columns
+}
+
+// ClassifyColumns 入力のヘッダー行を、注文データに対応付けた列と無視した列に分ける
+// missingには読み込む列のうち入力にない列を返す
+func ClassifyColumns(headers []string) (recognized, ignored, missing []string) {
+	known := map[string]bool{}
+	for _, c := range shopifyOrderColumns() {
+		known[c] = true
+	}
+	found := map[string]bool{}
+	for _, h := range headers {
+		h = strings.TrimSpace(h)
+		if known[h] {
+			recognized = append(recognized, h)
+			found[h] = true
+		} else {
+			ignored = append(ignored, h)
+		}
+	}
+	for _, c := range shopifyOrderColumns() {
+		if !found[c] {
+			missing = append(missing, c)
+		}
+	}
+	return recognized, ignored, missing
+}
+
+// reportColumns -verboseの場合に、入力のどの列を読み込み、どの列を無視したかを表示する
+// 注文番号の列がない場合は、ログやスキップした注文の注文番号が空になるので常に警告する
+func reportColumns(source string, headers []string) {
+	recognized, ignored, missing := ClassifyColumns(headers)
+	for _, c := range missing {
+		if c == "Name" {
+			warnf("注意: %s: 注文番号の列（%s）がありません\n", source, strings.Join(orderNameColumns, "、"))
+		}
+	}
+	debugf("%s: 読み込んだ列: %s\n", source, strings.Join(recognized, ", "))
+	if len(ignored) > 0 {
+		debugf("%s: 無視した列: %s\n", source, strings.Join(ignored, ", "))
+	}
+	if len(missing) > 0 {
+		debugf("%s: 入力にない列: %s\n", source, strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultContents 内容品のデフォルト
+var defaultContents = []string{"サプリメント"}
+
+// contentsSeparator 複数の内容品を1つの欄にまとめる際の区切り文字
+const contentsSeparator = "・"
+
+// ParseContents カンマ区切りの内容品を品目ごとに分ける
+func ParseContents(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
+
+// validateContents 内容品の各品目が空でないか検証する
+func validateContents(items []string) error {
+	for _, item := range items {
+		if item == "" {
+			return errors.New("内容品に空の品目があります")
+		}
+	}
+	return nil
+}
+
+// contentsItems 内容品の品目。指定がなければデフォルトを返す
+func (o ConvertOptions) contentsItems() []string {
+	if len(o.Contents) == 0 {
+		return defaultContents
+	}
+	return o.Contents
+}
+
+// JoinClickpostContents 内容品の品目をクリックポストの1つの内容品欄にまとめる
+// クリックポストの内容品欄は1つだけなので区切り文字でつなぎ、全体の長さはValidateで検証する
+func JoinClickpostContents(items []string) string {
+	return strings.Join(items, contentsSeparator)
+}
+
+// orderContentsItems 注文の内容品の品目。合計金額がしきい値以上の注文は高額注文用の内容品にし、highValueにtrueを返す
+// 合計金額が数値として読めない場合は通常の内容品にする。商品名の対応付けがある場合は、商品名に対応する内容品にする
+func (o ConvertOptions) orderContentsItems(s ShopifyOrder) (items []string, highValue bool) {
+	if o.HighValueThreshold > 0 && o.HighValueContents != "" {
+		if total, ok := parseOrderTotal(s.Total); ok && total >= o.HighValueThreshold {
+			return []string{o.HighValueContents}, true
+		}
+	}
+	if items, _ := o.mappedContentsItems(s); items != nil {
+		return items, false
+	}
+	return o.orderDefaultContents(s), false
+}
+
+// orderDefaultContents 商品名の対応付けなどがない注文の内容品。読み込んだファイルの内容品、内容品のテンプレート、-contentsの順に使う
+// テンプレートを展開できない注文はToClickpostShippingLabelsでエラーにするので、ここでは-contentsにする
+func (o ConvertOptions) orderDefaultContents(s ShopifyOrder) []string {
+	if s.DefaultContents != "" {
+		return ParseContents(s.DefaultContents)
+	}
+	if o.ContentsTemplate != nil {
+		if items, err := o.templateContentsItems(s); err == nil {
+			return items
+		}
+	}
+	return o.contentsItems()
+}
+
+// splitInputContents -inの「orders_tea.csv:お茶」をファイルと内容品に分ける
+// 「:」の前がCSV・Excel・JSONのファイル名で、後ろに「/」を含まない場合だけ内容品とみなすので、URLのポート番号やWindowsのドライブ名は分けない
+func splitInputContents(src string) (path, contents string) {
+	i := strings.LastIndex(src, ":")
+	if i < 0 {
+		return src, ""
+	}
+	path, contents = src[:i], src[i+1:]
+	ext := strings.ToLower(filepath.Ext(path))
+	if (ext != ".csv" && ext != ".xlsx" && ext != ".json") || strings.TrimSpace(contents) == "" || strings.ContainsAny(contents, `/\`) {
+		return src, ""
+	}
+	return path, contents
+}
+
+// validateFileContents ファイルごとの内容品を-contentsと同じく検証する。合わせた内容品が配送業者の1つの欄に収まるかも確かめる
+func validateFileContents(src, contents string, c *Carrier) error {
+	if contents == "" {
+		return nil
+	}
+	items := ParseContents(contents)
+	if err := validateContents(items); err != nil {
+		return fmt.Errorf("%sの%w", src, err)
+	}
+	if n := c.maxLen("ShippingContents"); n > 0 && utf8.RuneCountInString(JoinClickpostContents(items)) > n {
+		return fmt.Errorf("%sの内容品は全角%d文字までです: %s", src, n, JoinClickpostContents(items))
+	}
+	return nil
+}
+
+// parseOrderTotal Shopifyの合計金額（「12000.00」「¥12,000」など）を数値にする
+func parseOrderTotal(s string) (float64, bool) {
+	s = strings.NewReplacer(",", "", "¥", "", "￥", "", "円", "").Replace(strings.TrimSpace(s))
+	total, err := strconv.ParseFloat(s, 64)
+	return total, err == nil
+}
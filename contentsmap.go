@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// lineitemSeparator まとめた注文の商品名の区切り文字。商品名に改行は入らない
+const lineitemSeparator = "\n"
+
+// lineitemNames 注文の商品名。MergeShopifyOrdersで商品ごとの行をまとめた注文は複数になる
+func (s ShopifyOrder) lineitemNames() []string {
+	var names []string
+	for _, name := range strings.Split(s.LineitemName, lineitemSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// LoadContentsMap 商品名から内容品への対応付けをJSONファイルから読み込む
+// {"サプリA": "サプリメント", "プロテイン 1kg": "健康食品"} のように書く
+func LoadContentsMap(filename string) (map[string]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", filename, err)
+	}
+	for title, item := range m {
+		if strings.TrimSpace(item) == "" {
+			return nil, fmt.Errorf("%sの「%s」の内容品が空です", filename, title)
+		}
+	}
+	return m, nil
+}
+
+// normalizeTitle あいまいな照合のために商品名を正規化する。NFKCで全角・半角を揃え、空白を取り除き、小文字にする
+func normalizeTitle(s string) string {
+	s = norm.NFKC.String(s)
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.ToLower(s)
+}
+
+// lookupContents 商品名に対応する内容品を返す。完全に一致する商品名を優先し、
+// FuzzyContentsMapの場合は正規化した商品名が一致するものを探す。同じ正規化の商品名が複数ある場合は使わない
+func (o ConvertOptions) lookupContents(title string) (string, bool) {
+	if item, ok := o.ContentsMap[title]; ok {
+		return item, true
+	}
+	if !o.FuzzyContentsMap {
+		return "", false
+	}
+	key := normalizeTitle(title)
+	var found string
+	var n int
+	for t, item := range o.ContentsMap {
+		if normalizeTitle(t) == key {
+			found, n = item, n+1
+		}
+	}
+	return found, n == 1
+}
+
+// mappedContentsItems 商品名の対応付けから注文の内容品の品目を返す。同じ品目は1つにまとめる
+// 対応付けにない商品名は通常の内容品にし、unmatchedに返す。対応付けがない場合や商品名がない注文はitemsにnilを返す
+func (o ConvertOptions) mappedContentsItems(s ShopifyOrder) (items, unmatched []string) {
+	titles := s.lineitemNames()
+	if len(o.ContentsMap) == 0 || len(titles) == 0 {
+		return nil, nil
+	}
+	seen := map[string]bool{}
+	add := func(item string) {
+		if !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	for _, title := range titles {
+		item, ok := o.lookupContents(title)
+		if !ok {
+			unmatched = append(unmatched, title)
+			for _, item := range o.orderDefaultContents(s) {
+				add(item)
+			}
+			continue
+		}
+		add(item)
+	}
+	return items, unmatched
+}
+
+// UnmatchedLineitemNames 内容品の対応付けにない商品名を、最初に現れた順に重複なく返す
+// 対応付けを書き足すために、通常の内容品にした商品名を知らせる
+func UnmatchedLineitemNames(orders []*ShopifyOrder, opts ConvertOptions) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, o := range orders {
+		_, unmatched := opts.mappedContentsItems(*o)
+		for _, name := range unmatched {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// contentsTemplateData -contents-templateに渡す値。ShopifyOrderの項目（{{.Name}}など）に加え、商品名を1つずつに分けた{{.Lineitems}}を使える
+type contentsTemplateData struct {
+	ShopifyOrder
+	Lineitems []string
+}
+
+// ParseContentsTemplate 注文ごとの内容品のテンプレートを読む。例: {{.Name}}のご注文品
+// 存在しない項目の誤りは注文を変換するまで分からないので、テンプレートの記入例の注文で試しに展開して確かめる
+func ParseContentsTemplate(s string) (*template.Template, error) {
+	t, err := template.New("contents").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("-contents-templateを読めません: %w", err)
+	}
+	var b strings.Builder
+	sample := contentsTemplateData{ShopifyOrder: *sampleShopifyOrder, Lineitems: defaultContents}
+	if err := t.Execute(&b, sample); err != nil {
+		return nil, fmt.Errorf("-contents-templateを展開できません: %w", err)
+	}
+	return t, nil
+}
+
+// templateContentsItems テンプレートを注文で展開した内容品の品目。カンマ区切りの場合は-contentsと同じく複数の品目にする
+// 展開した内容品の文字数は、ほかの内容品と同じく送り状の検証で確かめる
+func (o ConvertOptions) templateContentsItems(s ShopifyOrder) ([]string, error) {
+	var b strings.Builder
+	if err := o.ContentsTemplate.Execute(&b, contentsTemplateData{ShopifyOrder: s, Lineitems: s.lineitemNames()}); err != nil {
+		return nil, fmt.Errorf("-contents-templateを展開できません: %w", err)
+	}
+	items := ParseContents(b.String())
+	if err := validateContents(items); err != nil {
+		return nil, fmt.Errorf("-contents-templateの%w: %q", err, b.String())
+	}
+	return items, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestContentsTemplate 注文番号を使うテンプレートを注文ごとに展開し、展開した内容品を送り状の文字数の上限で検証する
+func TestContentsTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool // 展開した内容品が空でToClickpostShippingLabelsがエラーになる
+		tooLong  bool // 送り状の検証で内容品が長すぎる
+	}{
+		{name: "注文番号", template: "{{.Name}}のご注文品", want: "#1001のご注文品"},
+		{name: "商品名", template: "{{index .Lineitems 0}}（{{.Name}}）", want: "Tシャツ（#1001）"},
+		{name: "上限を超える", template: "{{.Name}}のご注文品（衣料品と雑貨など）", want: "#1001のご注文品（衣料品と雑貨など）", tooLong: true},
+		{name: "空の品目", template: "{{if .ShippingCompany}}{{.Name}}{{end}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseContentsTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := DefaultConvertOptions()
+			opts.ContentsTemplate = tmpl
+			o := ShopifyOrder{
+				Name: "#1001", ShippingName: "山田太郎", ShippingZip: "150-0041",
+				ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "神南1-2-3", LineitemName: "Tシャツ",
+			}
+			labels, err := o.ToClickpostShippingLabels(opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ToClickpostShippingLabelsがエラーになりません: %q", labels[0].ShippingContents)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := labels[0].ShippingContents; got != tt.want {
+				t.Errorf("内容品 = %q、%qを期待", got, tt.want)
+			}
+			err = labels[0].Validate()
+			var ve *ValidationError
+			if tt.tooLong != (errors.As(err, &ve) && ve.Code == "contents_too_long") {
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+}
+
+// TestParseContentsTemplateErrors 読めないテンプレートや存在しない項目は、変換を始める前にエラーにする
+func TestParseContentsTemplateErrors(t *testing.T) {
+	for _, s := range []string{"{{.Name", "{{.Note}}", "{{index .Lineitems 5}}"} {
+		if _, err := ParseContentsTemplate(s); err == nil {
+			t.Errorf("ParseContentsTemplate(%q)がエラーになりません", s)
+		}
+	}
+}
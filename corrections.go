@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+)
+
+// ApplyCorrections 修正のCSVの空欄ではない項目で、同じ注文番号の注文データを上書きする
+// 修正のCSVはShopifyの注文データと同じ列名で、注文番号と直す列だけを書く。注文番号は正規化して照合する
+// 送り状にできなかった注文をスプレッドシートで直し、Shopifyのエクスポートを書き換えずに読み込み直すために使う
+// correctedには上書きした注文の数、unmatchedには入力にない修正の注文番号を返す
+func ApplyCorrections(orders, corrections []*ShopifyOrder) (corrected int, unmatched []string) {
+	byName := map[string]*ShopifyOrder{}
+	for _, o := range orders {
+		byName[NormalizeOrderName(o.Name)] = o
+	}
+	seen := map[string]bool{}
+	for _, c := range corrections {
+		name := NormalizeOrderName(c.Name)
+		o, ok := byName[name]
+		if !ok {
+			unmatched = append(unmatched, c.Name)
+			continue
+		}
+		overrideFields(o, c)
+		if !seen[name] {
+			seen[name] = true
+			corrected++
+		}
+	}
+	return corrected, unmatched
+}
+
+// overrideFields dstの文字列項目を、srcの空欄ではない値で上書きする。注文番号は変えない
+func overrideFields(dst, src *ShopifyOrder) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	t := d.Type()
+	for i := 0; i < d.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("csv"); tag == "-" || t.Field(i).Name == "Name" {
+			continue
+		}
+		if f := s.Field(i); f.Kind() == reflect.String && f.String() != "" {
+			d.Field(i).SetString(f.String())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestApplyCorrections 修正の空欄ではない項目だけを上書きし、入力にない注文番号を返す
+func TestApplyCorrections(t *testing.T) {
+	orders := []*ShopifyOrder{
+		{Name: "#1", ShippingName: "山田太郎", ShippingZip: "", ShippingCity: "渋谷区", ShippingStreet: "神南1-2-3"},
+		{Name: "#2", ShippingName: "佐藤花子", ShippingZip: "150-0042", ShippingCity: "渋谷区", ShippingStreet: "宇田川町1-1"},
+	}
+	corrections := []*ShopifyOrder{
+		{Name: "1", ShippingZip: "150-0041"},
+		{Name: "＃1", ShippingStreet: "神南1-2-4"},
+		{Name: "#9", ShippingZip: "100-0001"},
+	}
+	corrected, unmatched := ApplyCorrections(orders, corrections)
+	if corrected != 1 {
+		t.Errorf("上書きした注文 = %d件、#1の1件を期待", corrected)
+	}
+	if !reflect.DeepEqual(unmatched, []string{"#9"}) {
+		t.Errorf("入力にない注文 = %v、[#9]を期待", unmatched)
+	}
+	want := ShopifyOrder{Name: "#1", ShippingName: "山田太郎", ShippingZip: "150-0041", ShippingCity: "渋谷区", ShippingStreet: "神南1-2-4"}
+	if *orders[0] != want {
+		t.Errorf("#1 = %+v、%+vを期待", *orders[0], want)
+	}
+	if orders[1].ShippingZip != "150-0042" || orders[1].ShippingStreet != "宇田川町1-1" {
+		t.Errorf("修正のない#2が変わりました: %+v", *orders[1])
+	}
+}
+
+// TestRunCorrections -correctionsで郵便番号を直した注文は送り状になり、入力にない修正の注文は注意として知らせる
+func TestRunCorrections(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeOrdersCSV(t, "orders.csv", 3, "#2")
+	corrections := "Name,Shipping Zip\n#2,150-0042\n#9,100-0001\n"
+	if err := os.WriteFile("corrections.csv", []byte(corrections), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in = stringsFlag{"orders.csv"}
+	t.Cleanup(func() { in = nil })
+	setFlags(t, map[string]string{"corrections": "corrections.csv"})
+	logs := captureLog(t)
+
+	if err := run(); err != nil {
+		t.Fatalf("runのエラー = %v、nilを期待", err)
+	}
+	_, labels, err := ReadClickpostShippingLabels("clickpost-shipping-labels-0.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 3 {
+		t.Fatalf("送り状 = %d件、3件を期待", len(labels))
+	}
+	if labels[1].ShippingName != "山田2郎" || labels[1].ShippingZip != "150-0042" {
+		t.Errorf("2件目の送り状 = %s %s、山田2郎 150-0042を期待", labels[1].ShippingName, labels[1].ShippingZip)
+	}
+	if !strings.Contains(logs.String(), "修正のCSVの注文#9は入力にありません") {
+		t.Errorf("入力にない修正の注文がログにありません:\n%s", logs)
+	}
+}
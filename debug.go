@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// DebugShiftJISBytes 先頭の注文を送り状に変換し、エクスポートと同じWriterで書き出したバイト列の16進ダンプと、
+// それを出力の文字コード（-encoding）で読み戻した文字列をwに出力する
+// 配送業者に文字コードの問題でファイルを弾かれたときの調査用
+func DebugShiftJISBytes(w io.Writer, orders []*ShopifyOrder, opts ConvertOptions, eopts ExportOptions) error {
+	if len(orders) == 0 {
+		return errors.New("注文がありません")
+	}
+	o := orders[0]
+	labels := []*ClickpostShippingLabel{o.ToClickpostShippingLabel(opts)}
+	var buf bytes.Buffer
+	if err := encodeCSV(&buf, &labels, eopts); err != nil {
+		return err
+	}
+	decoded := buf.Bytes()
+	switch eopts.Encoding {
+	case EncodingUTF8BOM:
+		decoded = bytes.TrimPrefix(decoded, []byte("\xEF\xBB\xBF"))
+	case EncodingUTF8:
+	default:
+		var err error
+		if decoded, _, err = transform.Bytes(japanese.ShiftJIS.NewDecoder(), decoded); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "注文番号:%s\n", o.Name)
+	fmt.Fprint(w, hex.Dump(buf.Bytes()))
+	fmt.Fprintf(w, "読み戻した文字列:\n%s", decoded)
+	return nil
+}
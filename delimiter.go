@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// delimiterNames 書き出しの誤りで使われやすい区切り文字と、メッセージに表示する名前
+var delimiterNames = []struct {
+	Delimiter string
+	Name      string
+}{
+	{"\t", "タブ"},
+	{";", "セミコロン（;）"},
+	{"|", "縦棒（|）"},
+}
+
+// checkSingleColumnHeader ヘッダー行が1つの列として読み込まれた場合に、区切り文字の誤りを説明するエラーを返す
+// 行全体が「"」で囲まれていたり、カンマ以外で区切られていたりすると、CSVとしては正しく読めても、すべての項目が空欄の注文になってしまう
+func checkSingleColumnHeader(headers []string) error {
+	if len(headers) != 1 {
+		return nil
+	}
+	header := headers[0]
+	if strings.Contains(header, ",") {
+		return fmt.Errorf("%w: 1行目が「%s」の1つの列として読み込まれました。行全体が「\"」で囲まれています。項目ごとにカンマで区切って書き出し直してください", ErrInputParse, excerpt(header))
+	}
+	for _, d := range delimiterNames {
+		if strings.Contains(header, d.Delimiter) {
+			return fmt.Errorf("%w: 1行目が「%s」の1つの列として読み込まれました。%s区切りで書き出されているようです。区切り文字をカンマにして書き出し直してください", ErrInputParse, excerpt(header), d.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestImportQuotedRows 行全体を「"」で囲んだtestdata/orders-quoted-rows.csvは、空欄の注文にせず区切り文字の誤りを説明するエラーにする
+func TestImportQuotedRows(t *testing.T) {
+	orders, err := ImportShopifyOrders("testdata/orders-quoted-rows.csv", InputEncodingUTF8)
+	if !errors.Is(err, ErrInputParse) {
+		t.Fatalf("ImportShopifyOrdersのエラー = %v、ErrInputParseを期待", err)
+	}
+	if orders != nil {
+		t.Errorf("注文 = %d件、なしを期待", len(orders))
+	}
+	if !strings.Contains(err.Error(), "行全体が「\"」で囲まれています") {
+		t.Errorf("エラー = %v、行全体が囲まれている説明を期待", err)
+	}
+}
+
+// TestCheckSingleColumnHeader カンマ以外で区切られたCSVは区切り文字の名前を示し、本当に1列のCSVはエラーにしない
+func TestCheckSingleColumnHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string // エラーのメッセージに含まれる文字列。空の場合はエラーにしない
+	}{
+		{name: "タブ区切り", csv: "Name\tShipping Name\n#1\t山田太郎\n", want: "タブ区切り"},
+		{name: "セミコロン区切り", csv: "Name;Shipping Name\n#1;山田太郎\n", want: "セミコロン（;）区切り"},
+		{name: "縦棒区切り", csv: "Name|Shipping Name\n#1|山田太郎\n", want: "縦棒（|）区切り"},
+		{name: "1列のCSV", csv: "Name\n#1\n"},
+		{name: "カンマ区切り", csv: "Name,Shipping Name\n#1,山田太郎\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseShopifyCSV(strings.NewReader(tt.csv), InputEncodingUTF8, nil)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ParseShopifyCSVのエラー = %v、nilを期待", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInputParse) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseShopifyCSVのエラー = %v、「%s」を含むErrInputParseを期待", err, tt.want)
+			}
+		})
+	}
+}
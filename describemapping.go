@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+)
+
+// mappingExampleOrders 変換の過程を記録する例の注文。入力用CSVのテンプレートの注文と、氏名が空欄の会社宛ての注文
+// 設定によって使う入力項目が変わる分岐を通るように選ぶ
+func mappingExampleOrders() []*ShopifyOrder {
+	company := *sampleShopifyOrder
+	company.Name, company.ShippingName, company.ShippingCompany = "#1002", "", "株式会社サンプル"
+	return []*ShopifyOrder{sampleShopifyOrder, &company}
+}
+
+// DescribeMapping 送り状の項目ごとに、どのShopifyの項目からどう組み立てるかを今の設定でwに出力する
+// 説明は例の注文を実際に変換したときの過程の記録から作るので、変換の処理と食い違わない
+func DescribeMapping(w io.Writer, opts ConvertOptions) {
+	traces := map[string][]string{}
+	opts.Trace = func(field, detail string) {
+		if !slices.Contains(traces[field], detail) {
+			traces[field] = append(traces[field], detail)
+		}
+	}
+	var example *ClickpostShippingLabel
+	for i, o := range mappingExampleOrders() {
+		label := o.ToClickpostShippingLabel(opts)
+		if i == 0 {
+			example = label
+		}
+	}
+
+	fmt.Fprintln(w, "送り状の項目と、組み立てに使うShopifyの項目:")
+	lv := reflect.ValueOf(example).Elem()
+	for i := 0; i < lv.NumField(); i++ {
+		field := lv.Type().Field(i)
+		if tag := field.Tag.Get("csv"); tag == "" || tag == "-" {
+			continue
+		}
+		fmt.Fprintf(w, "  %s（%s） 例: %q\n", csvHeader(lv.Type(), field.Name), field.Name, lv.Field(i).String())
+		if len(traces[field.Name]) == 0 {
+			fmt.Fprintln(w, "    ← 空欄")
+		}
+		for _, detail := range traces[field.Name] {
+			fmt.Fprintf(w, "    ← %s\n", detail)
+		}
+	}
+	if len(traces[""]) > 0 {
+		fmt.Fprintln(w, "項目によらない処理:")
+		for _, detail := range traces[""] {
+			fmt.Fprintf(w, "  %s\n", detail)
+		}
+	}
+	fmt.Fprintf(w, "例は入力用CSVのテンプレートの注文（%s）を変換したものです。-explainで注文ごとの変換の過程を確かめられます\n", sampleShopifyOrder.Name)
+}
@@ -0,0 +1,20 @@
+package main
+
+// EstimateLabelCount エクスポートした場合に作られる送り状の枚数を返す。ファイルは書き込まない
+// 実際のエクスポートと同じ枚数になるよう、同じ注文番号の行をまとめてから同じ変換と検証を通す
+// heavyがnilでなければ、エクスポートと同じくTotal Weightがthresholdを超える注文をheavyの配送業者の検証ルールで数える
+// 注文番号の絞り込みは呼び出し側で済ませた注文データを渡す
+func EstimateLabelCount(orders []*ShopifyOrder, opts ConvertOptions, threshold float64, heavy *CarrierConfig) int {
+	orders = MergeShopifyOrders(orders)
+	n := 0
+	if heavy != nil {
+		var heavyOrders []*ShopifyOrder
+		orders, heavyOrders, _ = SplitOrdersByWeight(orders, threshold)
+		heavyOpts := opts
+		heavyOpts.Carrier = heavy.Carrier
+		labels, _ := BuildClickpostShippingLabels(heavyOrders, heavyOpts)
+		n += len(labels)
+	}
+	labels, _ := BuildClickpostShippingLabels(orders, opts)
+	return n + len(labels)
+}
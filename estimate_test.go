@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestEstimateLabelCountMatchesExport -countの枚数が、同じ注文と同じフラグでエクスポートした送り状の枚数と一致する
+func TestEstimateLabelCountMatchesExport(t *testing.T) {
+	// ゆうパックはクリックポストより長い氏名を受け付けるが、住所3行目は受け付けない
+	const carriers = `{"carriers": {"yupack": {"max_labels": 30, "fields": [
+		{"field": "ShippingZip", "code": "zip", "label": "郵便番号", "required": true},
+		{"field": "ShippingName", "code": "name", "label": "氏名", "required": true, "max_len": 30},
+		{"field": "ShippingAddress3", "code": "address3", "label": "住所3行目", "max_len": 1}
+	], "columns": [{"header": "郵便番号", "field": "ShippingZip"}, {"header": "氏名", "field": "ShippingName"}]}}}`
+	// 重さ、氏名、Shipping Address2、箱数の注文
+	orders := [][4]string{
+		{"500", "山田太郎", "", ""},
+		{"500", strings.Repeat("長", 25), "", ""},
+		{"500", "佐藤花子", "", "3"},
+		{"5000", strings.Repeat("長", 25), "", ""},
+		{"5000", "鈴木一郎", "渋谷マンション301", ""},
+		{"5000", "高橋次郎", "", "2"},
+		{"", "田中三郎", "", ""},
+	}
+	tests := []struct {
+		name  string
+		flags map[string]string
+	}{
+		{name: "クリックポストだけ", flags: map[string]string{}},
+		{name: "重さで振り分け", flags: map[string]string{"carriers": "carriers.json", "weight-threshold": "2000", "heavy-carrier": "yupack"}},
+		{name: "局留めを別のファイルに", flags: map[string]string{"special-address": "separate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("carriers.json", []byte(carriers), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			b.WriteString("Name,Shipping Name,Shipping Street,Shipping Address1,Shipping Address2,Shipping City,Shipping Zip,Shipping Province,Total Weight,Box Count\n")
+			for i, o := range orders {
+				street := "神南1-2-3"
+				if i == 2 {
+					street = "渋谷郵便局留"
+				}
+				fmt.Fprintf(&b, "#%d,%s,%s,,%s,渋谷区,150-0041,東京都,%s,%s\n", i+1, o[1], street, o[2], o[0], o[3])
+			}
+			if err := os.WriteFile("orders.csv", []byte(b.String()), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			in = stringsFlag{"orders.csv"}
+			t.Cleanup(func() { in = nil })
+			setFlags(t, tt.flags)
+			captureLog(t)
+
+			var countErr error
+			out := captureStdout(t, func() {
+				*count = true
+				defer func() { *count = false }()
+				countErr = run()
+			})
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			estimate, err := strconv.Atoi(strings.TrimSpace(out))
+			if err != nil {
+				t.Fatalf("-countの出力 = %q", out)
+			}
+
+			run()
+			files, err := filepath.Glob("*-labels-*.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			exported := 0
+			for _, f := range files {
+				records, err := readExportRecords(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				exported += len(records) - 1
+			}
+			if estimate != exported {
+				t.Errorf("-count = %d、エクスポートした送り状の%d枚（%v）を期待", estimate, exported, files)
+			}
+		})
+	}
+}
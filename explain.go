@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// csvHeader 構造体のフィールド名からcsvタグのヘッダー名を返す
+func csvHeader(t reflect.Type, field string) string {
+	if f, ok := t.FieldByName(field); ok {
+		if tag := f.Tag.Get("csv"); tag != "" && tag != "-" {
+			return tag
+		}
+	}
+	return field
+}
+
+// ExplainOrder 注文データの入力項目と、送り状の各項目がどの入力項目からどう組み立てられたかをwに出力する
+// -maskの場合は、入力項目と送り状の個人情報を伏せる
+func ExplainOrder(w io.Writer, o *ShopifyOrder, opts ConvertOptions) {
+	traces := map[string][]string{}
+	opts.Trace = func(field, detail string) {
+		traces[field] = append(traces[field], detail)
+	}
+	label := o.ToClickpostShippingLabel(opts)
+	// 検証は伏せる前の送り状で行う
+	shown, shownLabel := o, label
+	if maskOutput {
+		shown, shownLabel = MaskShopifyOrder(o), MaskClickpostShippingLabel(label)
+	}
+
+	fmt.Fprintf(w, "注文番号:%s\n", o.Name)
+	fmt.Fprintln(w, "  入力:")
+	ov := reflect.ValueOf(shown).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		if f := ov.Field(i); f.Kind() == reflect.String {
+			fmt.Fprintf(w, "    %s: %q\n", csvHeader(ov.Type(), ov.Type().Field(i).Name), f.String())
+		}
+	}
+	for _, detail := range traces[""] {
+		fmt.Fprintf(w, "  %s\n", detail)
+	}
+	fmt.Fprintln(w, "  送り状:")
+	lv := reflect.ValueOf(shownLabel).Elem()
+	for i := 0; i < lv.NumField(); i++ {
+		field := lv.Type().Field(i)
+		if tag := field.Tag.Get("csv"); tag == "" || tag == "-" {
+			continue
+		}
+		fmt.Fprintf(w, "    %s: %q", csvHeader(lv.Type(), field.Name), lv.Field(i).String())
+		for _, detail := range traces[field.Name] {
+			fmt.Fprintf(w, " ← %s", detail)
+		}
+		fmt.Fprintln(w)
+	}
+	if err := opts.carrier().Validate(label); err != nil {
+		fmt.Fprintf(w, "  検証エラー: %v\n", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestExplainOrderMask -maskの場合は、入力項目と送り状の個人情報を伏せて出力する
+func TestExplainOrderMask(t *testing.T) {
+	o := &ShopifyOrder{
+		Name:             "#1001",
+		ShippingName:     "山田太郎",
+		ShippingStreet:   "神南",
+		ShippingAddress1: "1-2-3",
+		ShippingAddress2: "Room 301",
+		ShippingCity:     "渋谷区",
+		ShippingZip:      "150-0041",
+		ShippingProvince: "東京都",
+		ShippingPhone:    "090-1234-5678",
+		Notes:            "不在時は宅配ボックス",
+	}
+	opts := DefaultConvertOptions()
+	opts.NormalizeRoom = true
+	for _, mask := range []bool{false, true} {
+		maskOutput = mask
+		var b strings.Builder
+		ExplainOrder(&b, o, opts)
+		maskOutput = false
+		for _, value := range []string{"山田太郎", "150-0041", "神南1-2-3", "Room 301", "301号室", "090-1234-5678", "宅配ボックス"} {
+			if got := strings.Contains(b.String(), value); got == mask {
+				t.Errorf("mask=%v: 出力に「%s」がある = %v、%vを期待:\n%s", mask, value, got, !mask, b.String())
+			}
+		}
+		if !strings.Contains(b.String(), "東京都渋谷区") {
+			t.Errorf("mask=%v: 住所1行目の都道府県と市区町村がありません:\n%s", mask, b.String())
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// clickpostFilenameFormat チャンクごとの出力ファイル名。%dにチャンクの番号が入る
+const clickpostFilenameFormat = "clickpost-shipping-labels-%d.csv"
+
+// clickpostSingleFilename -single-fileの出力ファイル名
+const clickpostSingleFilename = "clickpost-shipping-labels.csv"
+
+// ErrFilenameNoIndex チャンクごとの出力ファイル名の書式から、チャンクごとに異なるファイル名にならない
+var ErrFilenameNoIndex = errors.New("ファイル名テンプレートにインデックスが含まれていません")
+
+// checkChunkFilenames チャンクごとの出力ファイル名がすべて異なるか、書き込む前に確かめる
+// 同じファイル名になるチャンクがあると、後のチャンクが前のファイルを上書きして送り状が失われる
+func checkChunkFilenames(filenameFormat string, n int) error {
+	seen := map[string]int{}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf(filenameFormat, i)
+		if strings.Contains(name, "%!") {
+			return fmt.Errorf("%w: %s", ErrFilenameNoIndex, filenameFormat)
+		}
+		if j, ok := seen[name]; ok {
+			return fmt.Errorf("%w: %s（%d番目と%d番目のチャンクが%sになります）", ErrFilenameNoIndex, filenameFormat, j, i, name)
+		}
+		seen[name] = i
+	}
+	return nil
+}
+
+// ChunkResult チャンクごとのエクスポート結果
+type ChunkResult struct {
+	Filename string                    // 出力ファイル名
+	Labels   []*ClickpostShippingLabel // エクスポートした送り状。-streamの場合は持たない
+	Rows     int                       // 書き込んだ送り状の枚数
+	Rejects  []*RejectedOrder          // スキップした注文
+	Err      error                     // 書き込みのエラー
+}
+
+// ExportChunks チャンクごとに送り状をファイルへエクスポートする。最大parallel件を並行して書き込む
+// チャンクごとのファイル名が重複する場合は、何も書き込まずに先頭の結果のErrにErrFilenameNoIndexを返す
+// 結果はチャンクの順に返すので、ファイル名やログの順序は並行数によらず同じになる
+func ExportChunks(chunks [][]*ShopifyOrder, filenameFormat string, parallel int, opts ConvertOptions, eopts ExportOptions) []*ChunkResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]*ChunkResult, len(chunks))
+	if err := checkChunkFilenames(filenameFormat, len(chunks)); err != nil {
+		// どのファイルも書き込まず、先頭の結果でエラーを返す
+		for i := range results {
+			results[i] = &ChunkResult{Filename: fmt.Sprintf(filenameFormat, i)}
+		}
+		if len(results) > 0 {
+			results[0].Err = err
+		}
+		return results
+	}
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, chunkedOrders := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunkedOrders []*ShopifyOrder) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			labels, rejects := BuildClickpostShippingLabels(chunkedOrders, opts)
+			result := &ChunkResult{Filename: fmt.Sprintf(filenameFormat, i), Rejects: rejects}
+			// 有効な送り状が1件もないチャンクはファイルを作らない
+			if len(labels) > 0 {
+				if result.Filename, result.Err = writeChunkLabels(result.Filename, labels, eopts); result.Err == nil {
+					result.Labels, result.Rows = labels, len(labels)
+				}
+			}
+			results[i] = result
+		}(i, chunkedOrders)
+	}
+	wg.Wait()
+	return results
+}
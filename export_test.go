@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExportChunksParallel 並行して書き込んでも、1件ずつ書き込んだ場合と同じ名前と内容のファイルになる
+func TestExportChunksParallel(t *testing.T) {
+	eopts, err := Clickpost.exportOptions("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := ChunkShopifyOrdersBy(ChunkModeGreedy, streamTestOrders(390), Clickpost.MaxLabels)
+	sequential, parallel := t.TempDir(), t.TempDir()
+	for dir, n := range map[string]int{sequential: 1, parallel: 4} {
+		for i, result := range ExportChunks(chunks, filepath.Join(dir, clickpostFilenameFormat), n, ConvertOptions{}, eopts) {
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if want := filepath.Join(dir, fmt.Sprintf(clickpostFilenameFormat, i)); result.Filename != want {
+				t.Errorf("-parallel %d: %d番目の結果のファイル = %s、%sを期待", n, i, result.Filename, want)
+			}
+		}
+	}
+	for i := range chunks {
+		name := fmt.Sprintf(clickpostFilenameFormat, i)
+		want, err := os.ReadFile(filepath.Join(sequential, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(parallel, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("並行して書き込んだ%sが1件ずつ書き込んだものと異なります", name)
+		}
+	}
+}
+
+// TestExportChunksRefusesOverLimitChunk 分割の誤りで上限を超えたチャンクは、ファイルを作らずにエラーにする
+func TestExportChunksRefusesOverLimitChunk(t *testing.T) {
+	eopts, err := Clickpost.exportOptions("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	chunks := [][]*ShopifyOrder{streamTestOrders(Clickpost.MaxLabels + 1)}
+	results := ExportChunks(chunks, filepath.Join(dir, clickpostFilenameFormat), 1, ConvertOptions{}, eopts)
+	if !errors.Is(results[0].Err, ErrTooManyLabels) {
+		t.Errorf("書き込みのエラー = %v、ErrTooManyLabelsを期待", results[0].Err)
+	}
+	if _, err := os.Stat(results[0].Filename); !os.IsNotExist(err) {
+		t.Errorf("上限を超えたチャンクの%sがあります: %v", results[0].Filename, err)
+	}
+}
+
+// TestExportChunksFilenameWithoutIndex ファイル名にチャンクの番号が入らない場合は、どのファイルも書き込まずにエラーにする
+func TestExportChunksFilenameWithoutIndex(t *testing.T) {
+	eopts, err := Clickpost.exportOptions("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	chunks := ChunkShopifyOrdersBy(ChunkModeGreedy, streamTestOrders(50), Clickpost.MaxLabels)
+	results := ExportChunks(chunks, filepath.Join(dir, "labels.csv"), 1, ConvertOptions{}, eopts)
+	if !errors.Is(results[0].Err, ErrFilenameNoIndex) {
+		t.Errorf("書き込みのエラー = %v、ErrFilenameNoIndexを期待", results[0].Err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) > 0 {
+		t.Errorf("ファイルを書き込みました: %v", files)
+	}
+}
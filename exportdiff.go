@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LabelFieldChange 前回の送り状から値が変わった列
+type LabelFieldChange struct {
+	Column string // 列名
+	Before string // 前回の値
+	After  string // 今回の値
+}
+
+// LabelDiff 前回の送り状と比べて値が変わった1枚の送り状
+type LabelDiff struct {
+	Key     string // 注文番号。前回のファイルに注文番号の列がない場合は行番号
+	Changes []LabelFieldChange
+}
+
+// ExportDiff 前回の出力と今の設定で作る送り状の違い
+type ExportDiff struct {
+	ByRow          bool     // 前回のファイルに注文番号の列がなく、行の順番で比べた
+	Added          []string // 今回だけにある送り状
+	Removed        []string // 前回だけにある送り状
+	Changed        []LabelDiff
+	Unchanged      int      // 値の変わらない送り状の枚数
+	AddedColumns   []string // 今回だけにある列。値は比べない
+	RemovedColumns []string // 前回だけにある列。値は比べない
+}
+
+// readExportRecords 出力済みの送り状のCSVをヘッダー行を含めて読み込む
+// -encodingや-retry-encodingでUTF-8にしたファイルもあるので、文字コードは-input-encoding autoと同じく判定する
+func readExportRecords(filename string) ([][]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = InputEncodingAuto.decode(bytes.TrimPrefix(b, []byte("\xEF\xBB\xBF"))); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err == nil && len(records) == 0 {
+		err = io.EOF
+	}
+	if err == io.EOF {
+		return nil, fmt.Errorf("%sが空です", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", filename, err)
+	}
+	return records, nil
+}
+
+// DiffExport 前回出力した送り状のファイルと、今の設定で作る送り状を列ごとに比べる
+// 正規化の設定を変えたときに、書き込む前に意図した送り状だけが変わるか確かめるために使う
+// 前回のファイルに-order-columnの注文番号の列があれば注文番号で、なければ行の順番で対応付ける
+// 複数のファイルを指定した場合は、チャンクの順に並べたものとして1つにつなげて比べる
+func DiffExport(filenames []string, labels []*ClickpostShippingLabel, eopts ExportOptions) (*ExportDiff, error) {
+	t := eopts.Template
+	if t == nil {
+		var err error
+		if t, err = ClickpostTemplate(nil); err != nil {
+			return nil, err
+		}
+	}
+	current := t.Records(labels)
+	var header []string
+	var previous [][]string
+	for _, filename := range filenames {
+		records, err := readExportRecords(filename)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			header = records[0]
+		} else if strings.Join(records[0], ",") != strings.Join(header, ",") {
+			return nil, fmt.Errorf("%sのヘッダー行がほかのファイルと一致しません", filename)
+		}
+		previous = append(previous, records[1:]...)
+	}
+
+	diff := &ExportDiff{}
+	previousIndex := map[string]int{}
+	for i, h := range header {
+		previousIndex[h] = i
+	}
+	var columns []string     // 両方にある列
+	var columnIndex [][2]int // 両方にある列の、前回と今回の位置
+	for j, h := range current[0] {
+		if i, ok := previousIndex[h]; ok {
+			columns = append(columns, h)
+			columnIndex = append(columnIndex, [2]int{i, j})
+			delete(previousIndex, h)
+		} else {
+			diff.AddedColumns = append(diff.AddedColumns, h)
+		}
+	}
+	for _, h := range header {
+		if _, ok := previousIndex[h]; ok {
+			diff.RemovedColumns = append(diff.RemovedColumns, h)
+		}
+	}
+	compare := func(key string, before, after []string) {
+		d := LabelDiff{Key: key}
+		for k, c := range columns {
+			b, a := "", after[columnIndex[k][1]]
+			if i := columnIndex[k][0]; i < len(before) {
+				b = before[i]
+			}
+			if a != b {
+				d.Changes = append(d.Changes, LabelFieldChange{Column: c, Before: b, After: a})
+			}
+		}
+		if len(d.Changes) == 0 {
+			diff.Unchanged++
+			return
+		}
+		diff.Changed = append(diff.Changed, d)
+	}
+
+	orderColumn := -1
+	for i, h := range header {
+		if h == "注文番号" {
+			orderColumn = i
+		}
+	}
+	if orderColumn < 0 {
+		diff.ByRow = true
+		for i := 0; i < len(current)-1 || i < len(previous); i++ {
+			switch {
+			case i >= len(previous):
+				diff.Added = append(diff.Added, fmt.Sprintf("%d行目（%s）", i+2, labels[i].OrderName))
+			case i >= len(current)-1:
+				diff.Removed = append(diff.Removed, fmt.Sprintf("%d行目", i+2))
+			default:
+				compare(fmt.Sprintf("%d行目（%s）", i+2, labels[i].OrderName), previous[i], current[i+1])
+			}
+		}
+		return diff, nil
+	}
+
+	// 複数個口の送り状は同じ注文番号が続くので、何枚目かを付けて区別する
+	occurrenceKeys := func(names []string) []string {
+		seen := map[string]int{}
+		keys := make([]string, len(names))
+		for i, name := range names {
+			seen[name]++
+			keys[i] = name
+			if n := seen[name]; n > 1 {
+				keys[i] = fmt.Sprintf("%s（%d枚目）", name, n)
+			}
+		}
+		return keys
+	}
+	previousNames := make([]string, len(previous))
+	for i, record := range previous {
+		if orderColumn < len(record) {
+			previousNames[i] = record[orderColumn]
+		}
+	}
+	previousKeys := occurrenceKeys(previousNames)
+	previousRows := map[string][]string{}
+	for i, key := range previousKeys {
+		previousRows[key] = previous[i]
+	}
+	currentNames := make([]string, len(labels))
+	for i, l := range labels {
+		currentNames[i] = l.OrderName
+	}
+	for i, key := range occurrenceKeys(currentNames) {
+		before, ok := previousRows[key]
+		if !ok {
+			diff.Added = append(diff.Added, key)
+			continue
+		}
+		compare(key, before, current[i+1])
+		delete(previousRows, key)
+	}
+	for _, key := range previousKeys {
+		if _, ok := previousRows[key]; ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	return diff, nil
+}
+
+// PrintExportDiff 前回の出力との違いを表示する。-maskの場合は値の先頭の2文字以外を伏せる
+func PrintExportDiff(w io.Writer, diff *ExportDiff, mask bool) {
+	value := func(s string) string {
+		if mask {
+			s = maskRunes(s, 2)
+		}
+		return fmt.Sprintf("%q", s)
+	}
+	if diff.ByRow {
+		fmt.Fprintln(w, "注意: 前回のファイルに注文番号の列がないため、行の順番で比べます。-order-columnを付けて出力したファイルなら注文番号で比べます")
+	}
+	if len(diff.AddedColumns) > 0 {
+		fmt.Fprintf(w, "今回だけにある列: %s\n", strings.Join(diff.AddedColumns, ","))
+	}
+	if len(diff.RemovedColumns) > 0 {
+		fmt.Fprintf(w, "前回だけにある列: %s\n", strings.Join(diff.RemovedColumns, ","))
+	}
+	for _, key := range diff.Added {
+		fmt.Fprintf(w, "+ %s: 今回だけにあります\n", key)
+	}
+	for _, key := range diff.Removed {
+		fmt.Fprintf(w, "- %s: 前回だけにあります\n", key)
+	}
+	for _, d := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n", d.Key)
+		for _, c := range d.Changes {
+			fmt.Fprintf(w, "    %s: %s → %s\n", c.Column, value(c.Before), value(c.After))
+		}
+	}
+	fmt.Fprintf(w, "追加%d件 / 削除%d件 / 変更%d件 / 変更なし%d件\n", len(diff.Added), len(diff.Removed), len(diff.Changed), diff.Unchanged)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// isURL 入力がhttp(s)のURLかどうか
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// FetchShopifyOrders ShopifyのエクスポートのダウンロードURLから注文データを取得
+func FetchShopifyOrders(url string, timeout time.Duration, enc InputEncoding) ([]*ShopifyOrder, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("注文データのダウンロードに失敗しました: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("注文データのダウンロードに失敗しました: %s", resp.Status)
+	}
+	headers, orders, err := ParseShopifyCSV(resp.Body, enc, nil)
+	if err := skipMalformedRows(url, err); err != nil {
+		return nil, err
+	}
+	reportColumns(url, headers)
+	return orders, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// LoadFieldDefaults 送り状の項目が空欄の場合に入れるデフォルトの値を読み込む。キーはClickpostShippingLabelのフィールド名
+// 例: {"ShippingAddress4": "不在時は宅配ボックス", "ShippingContents": "雑貨"}
+func LoadFieldDefaults(filename string) (map[string]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", filename, err)
+	}
+	labelType := reflect.TypeOf(ClickpostShippingLabel{})
+	for field := range m {
+		if f, ok := labelType.FieldByName(field); !ok || f.Type.Kind() != reflect.String || field == "OrderName" {
+			return nil, fmt.Errorf("%sの「%s」は送り状のフィールドにありません", filename, field)
+		}
+	}
+	return m, nil
+}
+
+// validateFieldDefaults デフォルトの値がその項目の検証ルールを満たすか確かめる
+// デフォルトを入れたすべての注文がスキップされないよう、変換を始める前に確かめる
+func (o ConvertOptions) validateFieldDefaults() error {
+	for _, field := range o.fieldDefaultNames() {
+		for _, r := range o.carrier().Fields {
+			if r.Field != field {
+				continue
+			}
+			if err := o.carrier().validateField(r, o.FieldDefaults[field]); err != nil {
+				return fmt.Errorf("-field-defaultsの%sのデフォルト: %w", field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyFieldDefaults 組み立てた送り状の空欄の項目にデフォルトの値を入れる。値のある項目は変えない
+func (o ConvertOptions) applyFieldDefaults(l *ClickpostShippingLabel) {
+	v := reflect.ValueOf(l).Elem()
+	for _, field := range o.fieldDefaultNames() {
+		if f := v.FieldByName(field); f.String() == "" {
+			f.SetString(o.FieldDefaults[field])
+			o.trace(field, "空欄のため-field-defaultsの値")
+		}
+	}
+}
+
+// fieldDefaultNames デフォルトの値があるフィールド名を名前順に返す。エラーや変換の過程の順序を実行ごとに揃える
+func (o ConvertOptions) fieldDefaultNames() []string {
+	names := make([]string, 0, len(o.FieldDefaults))
+	for field := range o.FieldDefaults {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	return names
+}
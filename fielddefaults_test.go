@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestApplyFieldDefaults デフォルトの値は組み立てた送り状の空欄の項目にだけ入れ、値のある項目は変えない
+func TestApplyFieldDefaults(t *testing.T) {
+	opts := DefaultConvertOptions()
+	opts.FieldDefaults = map[string]string{"ShippingAddress3": "建物名なし", "ShippingAddress4": "不在時は宅配ボックス"}
+	withBuilding := ShopifyOrder{
+		ShippingName: "山田太郎", ShippingZip: "150-0041", ShippingProvince: "東京都", ShippingCity: "渋谷区",
+		ShippingStreet: "神南", ShippingAddress1: "1-2-3", ShippingAddress2: "渋谷マンション301",
+	}
+	l := withBuilding.ToClickpostShippingLabel(opts)
+	if l.ShippingAddress3 != "渋谷マンション301" || l.ShippingAddress4 != "不在時は宅配ボックス" {
+		t.Errorf("住所3・4行目 = %q %q、建物名のままと空欄の4行目にデフォルトの値を期待", l.ShippingAddress3, l.ShippingAddress4)
+	}
+	if l.ShippingAddress2 != "神南1-2-3" {
+		t.Errorf("住所2行目 = %q、注文データの値のままを期待", l.ShippingAddress2)
+	}
+	if err := l.Validate(); err != nil {
+		t.Errorf("Validate() = %v、nilを期待", err)
+	}
+
+	noBuilding := withBuilding
+	noBuilding.ShippingAddress2 = ""
+	l = noBuilding.ToClickpostShippingLabel(opts)
+	if l.ShippingAddress3 != "建物名なし" || l.ShippingAddress4 != "不在時は宅配ボックス" {
+		t.Errorf("住所3・4行目 = %q %q、どちらもデフォルトの値を期待", l.ShippingAddress3, l.ShippingAddress4)
+	}
+}
+
+// TestValidateFieldDefaults デフォルトの値がその項目の文字数の上限を超える場合は、変換を始める前にエラーにする
+func TestValidateFieldDefaults(t *testing.T) {
+	opts := DefaultConvertOptions()
+	opts.FieldDefaults = map[string]string{"ShippingAddress4": "不在時は宅配ボックス"}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Validate() = %v、nilを期待", err)
+	}
+	opts.FieldDefaults = map[string]string{"ShippingAddress4": "不在時は建物の裏手にある宅配ボックスへ入れてください"}
+	if err := opts.Validate(); err == nil {
+		t.Error("上限を超えるデフォルトの値がエラーになりません")
+	}
+}
+
+// TestLoadFieldDefaults 送り状のフィールド名にない項目や文字列ではないフィールドはエラーにする
+func TestLoadFieldDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{name: "送り状のフィールド", json: `{"ShippingAddress4": "不在時は宅配ボックス"}`},
+		{name: "CSVの列名", json: `{"お届け先住所4行目": "不在時は宅配ボックス"}`, wantErr: true},
+		{name: "文字列ではないフィールド", json: `{"ContentsItems": "雑貨"}`, wantErr: true},
+		{name: "注文番号", json: `{"OrderName": "#1"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "defaults.json")
+			if err := os.WriteFile(filename, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadFieldDefaults(filename)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadFieldDefaults(%s)のエラー = %v", tt.json, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+)
+
+// NormalizeOrderName 照合用に注文番号を正規化する。前後の空白と先頭の「#」を取り除く
+// Shopifyの「#1001」と倉庫システムの「1001」を同じ注文として扱うために使う
+func NormalizeOrderName(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.TrimPrefix(name, "#")
+	return strings.TrimPrefix(name, "＃")
+}
+
+// ParseOrderNames カンマ区切りの注文番号を正規化した集合にする
+func ParseOrderNames(s string) map[string]bool {
+	names := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		if name = NormalizeOrderName(name); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// OrderNameSet 注文データの注文番号を正規化した集合にする
+func OrderNameSet(orders []*ShopifyOrder) map[string]bool {
+	names := map[string]bool{}
+	for _, o := range orders {
+		if name := NormalizeOrderName(o.Name); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// FilterOrders 注文番号で注文データを絞り込む
+// includeが空でなければ含まれる注文だけを残し、excludeに含まれる注文を除く。注文番号は正規化して照合する
+func FilterOrders(orders []*ShopifyOrder, include, exclude map[string]bool) []*ShopifyOrder {
+	var filtered []*ShopifyOrder
+	for _, o := range orders {
+		name := NormalizeOrderName(o.Name)
+		if len(include) > 0 && !include[name] {
+			continue
+		}
+		if exclude[name] {
+			continue
+		}
+		filtered = append(filtered, o)
+	}
+	return filtered
+}
+
+// ParseTags カンマ区切りのタグを照合用に正規化した集合にする。Shopifyのタグは大文字・小文字を区別しない
+func ParseTags(s string) map[string]bool {
+	tags := map[string]bool{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+// HoldOrders 保留のタグが付いた注文を除く。除いた注文はheldに返す
+// holdTagsが空の場合はすべての注文を残す
+func HoldOrders(orders []*ShopifyOrder, holdTags map[string]bool) (kept, held []*ShopifyOrder) {
+	if len(holdTags) == 0 {
+		return orders, nil
+	}
+	for _, o := range orders {
+		hold := false
+		for tag := range ParseTags(o.Tags) {
+			hold = hold || holdTags[tag]
+		}
+		if hold {
+			held = append(held, o)
+			continue
+		}
+		kept = append(kept, o)
+	}
+	return kept, held
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFilterOrdersNormalizesName 「#」の有無にかかわらず注文番号で絞り込み、注文番号は元のまま残す
+func TestFilterOrdersNormalizesName(t *testing.T) {
+	orders := []*ShopifyOrder{{Name: "#1001"}, {Name: "#1002"}, {Name: "1003"}}
+	tests := []struct {
+		name    string
+		include string
+		exclude string
+		want    []string
+	}{
+		{name: "#付きで含める", include: "#1001,#1003", want: []string{"#1001", "1003"}},
+		{name: "#なしで含める", include: "1001, 1003", want: []string{"#1001", "1003"}},
+		{name: "全角の＃で含める", include: "＃1002", want: []string{"#1002"}},
+		{name: "#付きで除く", exclude: "#1002", want: []string{"#1001", "1003"}},
+		{name: "#なしで除く", exclude: "1002", want: []string{"#1001", "1003"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, o := range FilterOrders(orders, ParseOrderNames(tt.include), ParseOrderNames(tt.exclude)) {
+				got = append(got, o.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterOrders = %v、%vを期待", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLogRejectedOrdersStripPrefix -strip-order-prefixの場合だけ、ログの注文番号の先頭の「#」を取り除く
+func TestLogRejectedOrdersStripPrefix(t *testing.T) {
+	for _, tt := range []struct {
+		strip bool
+		want  string
+	}{
+		{strip: false, want: "注文番号:#1001 "},
+		{strip: true, want: "注文番号:1001 "},
+	} {
+		logs := captureLog(t)
+		logRejectedOrders([]*RejectedOrder{{Name: "#1001", Err: ErrZipRequired}}, tt.strip, nil)
+		if !strings.Contains(logs.String(), tt.want) {
+			t.Errorf("strip=%v: ログ = %q、%qを含むことを期待", tt.strip, logs, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// FixListEntry 修正リストの1行。Shopifyで注文を直す担当者向けに、スキップした注文の問題の項目と直し方をまとめる
+type FixListEntry struct {
+	OrderName  string `csv:"注文番号"`
+	Field      string `csv:"問題の項目"`
+	Value      string `csv:"現在の値"`
+	Rule       string `csv:"守られていないルール"`
+	Suggestion string `csv:"直し方"`
+}
+
+// BuildFixList スキップした注文から、注文番号順の修正リストを作る
+// 現在の値は、変換後の送り状でルールに引っかかった項目の値にする。項目によらない理由の場合は問題の項目と現在の値を空にする
+// -maskの場合は、現在の値の氏名・郵便番号・住所をプレビューと同じく伏せる
+func BuildFixList(rejects []*RejectedOrder, opts ConvertOptions) []*FixListEntry {
+	SortRejectedOrders(rejects)
+	entries := make([]*FixListEntry, 0, len(rejects))
+	for _, r := range rejects {
+		e := &FixListEntry{OrderName: r.Name, Rule: r.Err.Error()}
+		var ve *ValidationError
+		if errors.As(r.Err, &ve) {
+			// 文字数の超過の値は現在の値の列に書くので、ルールの列にはメッセージだけを書く
+			e.Rule = ve.Message
+			if rule, ok := opts.carrier().ruleByCode(ve.Code); ok {
+				e.Field = rule.Label
+				if r.Order != nil {
+					l := r.Order.ToClickpostShippingLabel(opts)
+					if maskOutput {
+						l = MaskClickpostShippingLabel(l)
+					}
+					e.Value = reflect.ValueOf(l).Elem().FieldByName(rule.Field).String()
+				}
+			} else if r.Order != nil && (ve.Code == "invalid_box_count" || ve.Code == "too_many_boxes") {
+				e.Field, e.Value = "Box Count", r.Order.BoxCount
+			}
+		}
+		if r.Order != nil {
+			e.Suggestion = SuggestFix(r.Order, opts, r.Err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// WriteFixList 修正リストを書き込む。日本語版のExcelで開けるよう、送り状と同じ文字コードで書き込む
+// スキップした注文がない場合も、前回の修正リストが残らないようヘッダー行だけを書き込む
+// 値や直し方にShift-JISで表せない文字がある場合は、書き込めずに終わらないよう置き換える
+func WriteFixList(filename string, rejects []*RejectedOrder, opts ConvertOptions, eopts ExportOptions) error {
+	entries := BuildFixList(rejects, opts)
+	if eopts.Encoding == "" || eopts.Encoding == EncodingShiftJIS {
+		for _, e := range entries {
+			e.Field, e.Value, e.Rule, e.Suggestion = shiftJISText(e.Field), shiftJISText(e.Value), shiftJISText(e.Rule), shiftJISText(e.Suggestion)
+		}
+	}
+	return writeCSV(filename, &entries, eopts)
+}
+
+// shiftJISText 「〜」（U+301C）をShift-JISの「～」にし、ほかのShift-JISで表せない文字を「?」に置き換える
+func shiftJISText(s string) string {
+	encoder := japanese.ShiftJIS.NewEncoder()
+	return strings.Map(func(r rune) rune {
+		if r == '〜' {
+			return '～'
+		}
+		if _, err := encoder.String(string(r)); err != nil {
+			return '?'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"strings"
+)
+
+// stringsFlag 複数回指定できる文字列のフラグ
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultHonorifics 氏名の末尾に入力されがちな敬称のデフォルト。敬称の列と重複しないよう取り除く
+var defaultHonorifics = []string{"御中", "様", "殿", "さん"}
+
+// honorifics 取り除く敬称。指定がなければデフォルトを返す
+func (o ConvertOptions) honorifics() []string {
+	if len(o.Honorifics) == 0 {
+		return defaultHonorifics
+	}
+	return o.Honorifics
+}
+
+// ParseHonorifics カンマ区切りの取り除く敬称を読む
+func ParseHonorifics(s string) ([]string, error) {
+	var honorifics []string
+	for _, h := range strings.Split(s, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			honorifics = append(honorifics, h)
+		}
+	}
+	if len(honorifics) == 0 {
+		return nil, errors.New("取り除く敬称を1つ以上指定してください。取り除かない場合は-strip-honorific=falseを指定してください")
+	}
+	return honorifics, nil
+}
+
+// stripHonorific 氏名の末尾の敬称を取り除き、取り除いた敬称を返す
+// 敬称は先に書いたものから照合する。敬称だけの氏名は取り除くと空欄になるので、そのまま返す
+func (o ConvertOptions) stripHonorific(name string) (string, string) {
+	for _, h := range o.honorifics() {
+		if !strings.HasSuffix(name, h) {
+			continue
+		}
+		if stripped := strings.TrimRight(strings.TrimSuffix(name, h), " 　"); stripped != "" {
+			return stripped, h
+		}
+	}
+	return name, ""
+}
+
+// HonorificRule 宛名の種類から敬称を決める規則
+type HonorificRule struct {
+	Keyword string // 氏名に含まれる文字列
+	Title   string // 氏名にKeywordを含む場合の敬称
+}
+
+// defaultHonorificRules 敬称の規則のデフォルト。先頭から順に照合し、どれにも当てはまらない場合は「様」にする
+// 「山田様方 田中花子」のような気付の宛名は、個人宛てとして「様」のまま残す
+var defaultHonorificRules = []HonorificRule{
+	{Keyword: "様方", Title: "様"},
+	{Keyword: "株式会社", Title: "御中"},
+	{Keyword: "有限会社", Title: "御中"},
+	{Keyword: "合同会社", Title: "御中"},
+	{Keyword: "(株)", Title: "御中"},
+	{Keyword: "（株）", Title: "御中"},
+	{Keyword: "クリニック", Title: "御中"},
+	{Keyword: "病院", Title: "御中"},
+	{Keyword: "医院", Title: "御中"},
+	{Keyword: "学校", Title: "御中"},
+	{Keyword: "事務所", Title: "御中"},
+}
+
+// ParseHonorificRules 「クリニック=御中,ギフト=お客様」の形式の敬称の規則を読む
+func ParseHonorificRules(s string) ([]HonorificRule, error) {
+	var rules []HonorificRule
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		keyword, title, ok := strings.Cut(entry, "=")
+		if keyword, title = strings.TrimSpace(keyword), strings.TrimSpace(title); !ok || keyword == "" || title == "" {
+			return nil, fmt.Errorf("敬称の規則は氏名に含まれる文字列=敬称の形式で指定してください: %s", entry)
+		}
+		rules = append(rules, HonorificRule{Keyword: keyword, Title: title})
+	}
+	return rules, nil
+}
+
+// honorificFor 氏名から敬称を決める。指定した規則をデフォルトの規則より先に照合する
+// 当てはまった規則のKeywordを返すので、呼び出し側で敬称の由来を記録できる
+func (o ConvertOptions) honorificFor(name string) (title, keyword string) {
+	for _, rules := range [][]HonorificRule{o.HonorificRules, defaultHonorificRules} {
+		for _, r := range rules {
+			if strings.Contains(name, r.Keyword) {
+				return r.Title, r.Keyword
+			}
+		}
+	}
+	return "様", ""
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestStripHonorific 氏名の末尾の敬称を取り除き、敬称の列と重複させない。-strip-honorific=falseの場合はそのまま残す
+func TestStripHonorific(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		strip     bool
+		wantName  string
+		wantTitle string
+	}{
+		{name: "敬称なし", input: "田中太郎", strip: true, wantName: "田中太郎", wantTitle: "様"},
+		{name: "様", input: "田中太郎様", strip: true, wantName: "田中太郎", wantTitle: "様"},
+		{name: "空白と様", input: "田中太郎　様", strip: true, wantName: "田中太郎", wantTitle: "様"},
+		{name: "殿", input: "田中太郎殿", strip: true, wantName: "田中太郎", wantTitle: "様"},
+		{name: "さん", input: "田中太郎さん", strip: true, wantName: "田中太郎", wantTitle: "様"},
+		{name: "御中", input: "株式会社サンプル御中", strip: true, wantName: "株式会社サンプル", wantTitle: "御中"},
+		{name: "敬称だけ", input: "様", strip: true, wantName: "様", wantTitle: "様"},
+		{name: "取り除かない", input: "田中太郎様", strip: false, wantName: "田中太郎様", wantTitle: "様"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			opts.StripHonorific = tt.strip
+			o := ShopifyOrder{ShippingName: tt.input}
+			l := o.ToClickpostShippingLabel(opts)
+			if l.ShippingName != tt.wantName || l.ShippingNameTitle != tt.wantTitle {
+				t.Errorf("氏名と敬称 = %q %q、%q %qを期待", l.ShippingName, l.ShippingNameTitle, tt.wantName, tt.wantTitle)
+			}
+		})
+	}
+}
+
+// TestConfiguredHonorifics -honorificsで指定した敬称だけを、先に書いたものから取り除く
+func TestConfiguredHonorifics(t *testing.T) {
+	all, err := ParseHonorifics(" 様, さま,サマ,殿,御中,さん,ちゃん,, ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"様", "さま", "サマ", "殿", "御中", "さん", "ちゃん"}; !slices.Equal(all, want) {
+		t.Fatalf("ParseHonorifics = %q、%qを期待", all, want)
+	}
+	for _, h := range all {
+		t.Run(h, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			opts.Honorifics = all
+			name, stripped := opts.stripHonorific("田中みどり" + h)
+			if name != "田中みどり" || stripped != h {
+				t.Errorf("stripHonorific = %q %q、田中みどり %qを期待", name, stripped, h)
+			}
+		})
+	}
+
+	// ギフトショップ向けに、ちゃんを取り除かない
+	opts := DefaultConvertOptions()
+	opts.Honorifics = []string{"様", "殿"}
+	if name, stripped := opts.stripHonorific("ゆいちゃん"); name != "ゆいちゃん" || stripped != "" {
+		t.Errorf("指定していない敬称のstripHonorific = %q %q、ゆいちゃんのままを期待", name, stripped)
+	}
+	if name, _ := opts.stripHonorific("田中太郎さん"); name != "田中太郎さん" {
+		t.Errorf("デフォルトにだけある敬称を取り除きました: %q", name)
+	}
+	// 先に書いた敬称から照合するので、「お客様」より先に「様」を書くと「様」だけを取り除く
+	opts.Honorifics = []string{"様", "お客様"}
+	if name, stripped := opts.stripHonorific("田中お客様"); name != "田中お客" || stripped != "様" {
+		t.Errorf("stripHonorific = %q %q、田中お客 様を期待", name, stripped)
+	}
+	opts.Honorifics = nil
+	if !slices.Equal(opts.honorifics(), defaultHonorifics) {
+		t.Errorf("指定がない敬称 = %q、デフォルトの%qを期待", opts.honorifics(), defaultHonorifics)
+	}
+	if _, err := ParseHonorifics(" , "); err == nil {
+		t.Error("敬称が空の指定がエラーになりません")
+	}
+}
+
+// TestHonorificRules 宛名の種類から敬称を決める。-honorific-rulesの規則はデフォルトの規則より先に照合する
+func TestHonorificRules(t *testing.T) {
+	custom, err := ParseHonorificRules("ギフト=お客様,クリニック=様")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		input string
+		rules []HonorificRule
+		want  string
+	}{
+		{name: "個人", input: "田中太郎", want: "様"},
+		{name: "会社", input: "株式会社サンプル", want: "御中"},
+		{name: "（株）", input: "サンプル（株）", want: "御中"},
+		{name: "クリニック", input: "渋谷クリニック", want: "御中"},
+		{name: "様方", input: "山田様方 田中花子", want: "様"},
+		{name: "様方の会社", input: "株式会社サンプル 山田様方", want: "様"},
+		{name: "設定の規則", input: "ギフト 田中太郎", rules: custom, want: "お客様"},
+		{name: "設定の規則でデフォルトを上書き", input: "渋谷クリニック", rules: custom, want: "様"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			opts.HonorificRules = tt.rules
+			o := ShopifyOrder{ShippingName: tt.input}
+			if got := o.ToClickpostShippingLabel(opts).ShippingNameTitle; got != tt.want {
+				t.Errorf("%sの敬称 = %q、%qを期待", tt.input, got, tt.want)
+			}
+		})
+	}
+	if _, err := ParseHonorificRules("御中"); err == nil {
+		t.Error("「=」のない規則がエラーになりません")
+	}
+}
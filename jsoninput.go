@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// InputFormat 読み込む注文データの形式
+type InputFormat string
+
+const (
+	// InputFormatCSV Shopifyから書き出したCSV。拡張子が.xlsxの場合はExcelのファイル
+	InputFormatCSV InputFormat = "csv"
+	// InputFormatJSON 注文データの配列のJSON。項目名はShopifyOrderのフィールド名
+	InputFormatJSON InputFormat = "json"
+)
+
+// ParseInputFormat 文字列から読み込む注文データの形式を返す
+func ParseInputFormat(s string) (InputFormat, error) {
+	switch format := InputFormat(s); format {
+	case InputFormatCSV, InputFormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("読み込む形式はcsv、jsonのいずれかを指定してください: %s", s)
+}
+
+// ImportShopifyOrdersJSON 注文データの配列のJSONをインポート
+// ファイルが存在しない場合はErrInputNotFound、JSONとして読み込めない場合はErrInputJSONを返す
+func ImportShopifyOrdersJSON(filename string) ([]*ShopifyOrder, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrInputNotFound, filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	orders, err := ParseShopifyJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInputJSON, filename, err)
+	}
+	return orders, nil
+}
+
+// ParseShopifyJSON 注文データの配列のJSONを読み込む。項目名は「ShippingName」のようなShopifyOrderのフィールド名で、値はすべて文字列
+// 項目名の誤りで住所などが空欄の送り状にならないよう、フィールド名にない項目はエラーにする
+func ParseShopifyJSON(r io.Reader) ([]*ShopifyOrder, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var orders []*ShopifyOrder
+	if err := dec.Decode(&orders); err != nil {
+		return nil, err
+	}
+	for i, o := range orders {
+		if o == nil {
+			return nil, fmt.Errorf("%d番目の注文がnullです", i+1)
+		}
+	}
+	return orders, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestImportShopifyOrdersJSON testdata/orders.jsonの注文を読み込み、CSVと同じ変換で送り状にする
+func TestImportShopifyOrdersJSON(t *testing.T) {
+	orders, err := ImportShopifyOrdersJSON("testdata/orders.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("注文 = %d件、2件を期待", len(orders))
+	}
+	if orders[0].Name != "#1001" || orders[1].ShippingCity != "大阪市北区" {
+		t.Errorf("注文 = %+v %+v、#1001と大阪市北区の注文を期待", *orders[0], *orders[1])
+	}
+	labels, rejects := BuildLabels(orders)
+	if len(rejects) > 0 {
+		t.Fatalf("スキップした注文 = %v、なしを期待", rejects)
+	}
+	if l := labels[0]; l.ShippingName != "山田太郎" || l.ShippingAddress1 != "東京都渋谷区" || l.ShippingAddress2 != "神南1-2-3" || l.ShippingAddress3 != "渋谷マンション301" {
+		t.Errorf("1件目の送り状 = %+v", *l)
+	}
+}
+
+// TestParseShopifyJSONErrors フィールド名にない項目やnullの注文はエラーにし、ないファイルはErrInputNotFoundにする
+func TestParseShopifyJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{name: "フィールド名にない項目", json: `[{"Name": "#1", "Shipping Name": "山田太郎"}]`},
+		{name: "null", json: `[{"Name": "#1"}, null]`},
+		{name: "配列ではない", json: `{"Name": "#1"}`},
+		{name: "文字列ではない値", json: `[{"Name": 1001}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseShopifyJSON(strings.NewReader(tt.json)); err == nil {
+				t.Errorf("ParseShopifyJSON(%s)がエラーになりません", tt.json)
+			}
+		})
+	}
+	if _, err := ImportShopifyOrdersJSON("testdata/missing.json"); !errors.Is(err, ErrInputNotFound) {
+		t.Errorf("ないファイルのエラー = %v、ErrInputNotFoundを期待", err)
+	}
+}
+
+// TestRunJSONInput -in-format jsonの注文データから送り状のファイルを書き込む
+func TestRunJSONInput(t *testing.T) {
+	dir := t.TempDir()
+	fixture, err := filepath.Abs("testdata/orders.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	in = stringsFlag{fixture}
+	t.Cleanup(func() { in = nil })
+	setFlags(t, map[string]string{"in-format": "json"})
+	captureLog(t)
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	_, labels, err := ReadClickpostShippingLabels("clickpost-shipping-labels-0.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 || labels[1].ShippingName != "佐藤花子" {
+		t.Errorf("送り状 = %d件、佐藤花子を含む2件を期待", len(labels))
+	}
+}
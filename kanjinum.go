@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// kanjiBanchiPattern 丁目・番地・番・号の前の漢数字
+var kanjiBanchiPattern = regexp.MustCompile(`([一二三四五六七八九十]+)(丁目|番地|番|号)`)
+
+// kanjiDigits 漢数字の1〜9
+var kanjiDigits = map[rune]int{'一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+
+// parseKanjiNumber 「五」「十二」「三十」「九十九」のような1〜99の漢数字を数値にする
+// それ以外の書き方（「一〇」など）は読めないものとしてfalseを返す
+func parseKanjiNumber(s string) (int, bool) {
+	r := []rune(s)
+	digit := func(i int) (int, bool) {
+		n, ok := kanjiDigits[r[i]]
+		return n, ok
+	}
+	switch {
+	case len(r) == 1 && r[0] == '十':
+		return 10, true
+	case len(r) == 1:
+		return digit(0)
+	case len(r) == 2 && r[0] == '十':
+		n, ok := digit(1)
+		return 10 + n, ok
+	case len(r) == 2 && r[1] == '十':
+		n, ok := digit(0)
+		return n * 10, ok
+	case len(r) == 3 && r[1] == '十':
+		tens, ok1 := digit(0)
+		ones, ok2 := digit(2)
+		return tens*10 + ones, ok1 && ok2
+	}
+	return 0, false
+}
+
+// NormalizeKanjiNumerals 住所の「三丁目五番二号」のような漢数字を「3丁目5番2号」にする
+// 丁目・番地・番・号の直前にある1〜99の漢数字だけを変換する。「一番町」のような町名や読めない書き方はそのまま残す
+func NormalizeKanjiNumerals(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range kanjiBanchiPattern.FindAllStringSubmatchIndex(s, -1) {
+		num, marker := s[m[2]:m[3]], s[m[4]:m[5]]
+		n, ok := parseKanjiNumber(num)
+		// 「百二番」のように前に読めない桁がある場合も、一部だけ変換しないようそのまま残す
+		if !ok || strings.ContainsAny(lastRune(s[:m[0]]), "〇百千万") || (marker == "番" && strings.HasPrefix(s[m[1]:], "町")) {
+			continue
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(strconv.Itoa(n) + marker)
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// lastRune 文字列の最後の1文字
+func lastRune(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return ""
+	}
+	return string(r[len(r)-1])
+}
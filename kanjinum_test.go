@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+// TestNormalizeKanjiNumerals 丁目・番地・番・号の前の1〜99の漢数字だけを算用数字にし、あいまいな書き方は残す
+func TestNormalizeKanjiNumerals(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "丁目番号", input: "神南三丁目五番二号", want: "神南3丁目5番2号"},
+		{name: "番地", input: "本町四番地", want: "本町4番地"},
+		{name: "十", input: "十丁目", want: "10丁目"},
+		{name: "十の位", input: "十二番", want: "12番"},
+		{name: "十の倍数", input: "三十号", want: "30号"},
+		{name: "99", input: "九十九番地", want: "99番地"},
+		{name: "算用数字はそのまま", input: "神南1-2-3", want: "神南1-2-3"},
+		{name: "町名の一番町", input: "一番町五番", want: "一番町5番"},
+		{name: "百の位", input: "百二番", want: "百二番"},
+		{name: "〇を使った書き方", input: "一〇番", want: "一〇番"},
+		{name: "読めない並び", input: "十十号", want: "十十号"},
+		{name: "丁目などがない", input: "三軒茶屋", want: "三軒茶屋"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeKanjiNumerals(tt.input); got != tt.want {
+				t.Errorf("NormalizeKanjiNumerals(%q) = %q、%qを期待", tt.input, got, tt.want)
+			}
+		})
+	}
+}
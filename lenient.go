@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// lenientImport 注文データのCSVの壊れた行を飛ばし、残りの行を読み込む。-lenient-importで設定する
+var lenientImport bool
+
+// RowError 注文データのCSVの読み込めなかった行
+type RowError struct {
+	Line int   // 行番号。ヘッダー行が1行目
+	Err  error // 読み込めなかった理由
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("%d行目: %s", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// MalformedRowsError 壊れた行を飛ばして読み込んだ。読み込めた注文データと一緒に返す
+type MalformedRowsError struct {
+	Rows []*RowError
+}
+
+func (e *MalformedRowsError) Error() string {
+	return fmt.Sprintf("読み込めない行が%d行あります", len(e.Rows))
+}
+
+// parseShopifyCSVRows 注文データのCSVを1行ずつ読み込み、列名が一致する項目に対応付ける
+// gocsvはどこか1行でも壊れていると全体を読み込めないので、壊れた行はrowErrsに返して次の行に進む
+// 列の数がヘッダー行と異なる行も、どの項目がずれたか分からないので壊れた行とみなす
+func parseShopifyCSVRows(b []byte) (orders []*ShopifyOrder, rowErrs []*RowError, err error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	headers, err := r.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	t := reflect.TypeOf(ShopifyOrder{})
+	fields := make([]int, len(headers))
+	for i, h := range headers {
+		fields[i] = -1
+		for j := 0; j < t.NumField(); j++ {
+			if tag := t.Field(j).Tag.Get("csv"); tag != "-" && tag == strings.TrimSpace(h) {
+				fields[i] = j
+				break
+			}
+		}
+	}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return orders, rowErrs, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrs = append(rowErrs, &RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) != len(headers) {
+			rowErrs = append(rowErrs, &RowError{Line: line, Err: fmt.Errorf("列の数が%d列です（ヘッダー行は%d列）", len(record), len(headers))})
+			continue
+		}
+		o := &ShopifyOrder{}
+		v := reflect.ValueOf(o).Elem()
+		for i, value := range record {
+			if fields[i] >= 0 {
+				v.Field(fields[i]).SetString(value)
+			}
+		}
+		orders = append(orders, o)
+	}
+}
+
+// skipMalformedRows 壊れた行を飛ばして読み込んだ場合に、飛ばした行を注意として表示する
+// それ以外のエラーはそのまま返す
+func skipMalformedRows(source string, err error) error {
+	var malformed *MalformedRowsError
+	if !errors.As(err, &malformed) {
+		return err
+	}
+	for _, row := range malformed.Rows {
+		warnf("注意: %s: 読み込めない行を飛ばします: %s\n", source, row)
+		auditLog.Log(logLevelWarn, "", "malformed_row", fmt.Sprintf("%s: %s", source, row))
+	}
+	return nil
+}
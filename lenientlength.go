@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseLenientLength 「address1,address2」の形式の、文字数の上限を超えても送り状にする項目を読む
+// 項目は-max-lenと同じく検証ルールのエラーコードの接頭辞で指定する
+func ParseLenientLength(s string) []string {
+	var codes []string
+	for _, code := range strings.Split(s, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// WithLenientLength codesの項目の文字数の上限を超えても、送り状をスキップしない配送業者の定義を返す。cは変更しない
+// 印刷時に切り詰めて受け付ける配送業者向けで、必須の項目が空欄の場合などはこれまでどおりスキップする
+func (c *Carrier) WithLenientLength(codes []string) (*Carrier, error) {
+	copied := *c
+	copied.Fields = append([]FieldRule(nil), c.Fields...)
+	for _, code := range codes {
+		i := slices.IndexFunc(copied.Fields, func(r FieldRule) bool { return r.Code == code })
+		if i < 0 {
+			return nil, fmt.Errorf("%sに%sの項目はありません", c.Name, code)
+		}
+		if copied.Fields[i].MaxLen == 0 {
+			return nil, fmt.Errorf("%sの%sには文字数の上限がありません", c.Name, code)
+		}
+		copied.Fields[i].LenientLength = true
+	}
+	return &copied, nil
+}
+
+// LengthViolation 文字数の上限を超えたまま書き込む送り状の項目
+type LengthViolation struct {
+	OrderName string // 注文番号
+	Label     string // 項目名
+	Length    int    // 文字数
+	MaxLen    int    // 上限
+}
+
+// FindLengthViolations -lenient-lengthで文字数の上限を超えたまま書き込む送り状の項目をすべて返す
+// 送り状は切り詰めずにそのまま書き込むので、印刷で切れても困らないか確かめられるよう表示する
+func FindLengthViolations(orders []*ShopifyOrder, opts ConvertOptions) []LengthViolation {
+	labels, _ := BuildClickpostShippingLabels(orders, opts)
+	var found []LengthViolation
+	for _, l := range labels {
+		for _, r := range opts.carrier().Fields {
+			if !r.LenientLength {
+				continue
+			}
+			if n := utf8.RuneCountInString(reflect.ValueOf(l).Elem().FieldByName(r.Field).String()); n > r.MaxLen {
+				found = append(found, LengthViolation{OrderName: l.OrderName, Label: r.Label, Length: n, MaxLen: r.MaxLen})
+			}
+		}
+	}
+	return found
+}
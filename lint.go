@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+)
+
+// LintClickpostShippingLabels 出力済みの送り状発行用CSVの各行を検証ルールで確かめ、すべての違反を行番号付きで返す
+// -verifyと異なり、ヘッダー行や件数は確かめず、1行に複数の違反がある場合もすべて報告する
+// 文字数の違反には検証エラーと同じく実際の値と文字数が添えられるので、手作業で編集した行をどれだけ短くすればよいか分かる
+// 検証ルールは、-max-lenなどで上書きした変換時と同じ配送業者cの定義を使う
+func LintClickpostShippingLabels(filename string, c *Carrier) ([]string, error) {
+	_, labels, err := ReadClickpostShippingLabels(filename)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	for i, label := range labels {
+		for _, err := range c.ValidateAll(label) {
+			// ヘッダー行の分を足して、ファイル上の行番号で表示する
+			problems = append(problems, fmt.Sprintf("%d行目: %s", i+2, localizeError(err)))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Locale コンソールに出力するメッセージの言語。出力するCSVの内容は翻訳しない
+type Locale string
+
+const (
+	// LocaleJA 日本語
+	LocaleJA Locale = "ja"
+	// LocaleEN 英語。海外の開発者がパイプラインを調べる場合向け
+	LocaleEN Locale = "en"
+)
+
+// ParseLocale 文字列からメッセージの言語を返す
+func ParseLocale(s string) (Locale, error) {
+	switch l := Locale(s); l {
+	case LocaleJA, LocaleEN:
+		return l, nil
+	}
+	return "", fmt.Errorf("メッセージの言語はjaかenを指定してください: %s", s)
+}
+
+// messageLocale コンソールに出力するメッセージの言語。-localeで切り替える
+var messageLocale = LocaleJA
+
+// fieldLabelsEN 検証ルールのエラーコードの接頭辞ごとの英語の項目名
+var fieldLabelsEN = map[string]string{
+	"zip":      "Recipient postal code",
+	"name":     "Recipient name",
+	"address1": "Address line 1",
+	"address2": "Address line 2",
+	"address3": "Address line 3",
+	"address4": "Address line 4",
+	"contents": "Contents",
+	"phone":    "Phone number",
+}
+
+// validationMessagesEN 項目によらない検証エラーの英語のメッセージ。エラーコードで引く
+var validationMessagesEN = map[string]string{
+	"invalid_box_count":      "Box count must be an integer of 1 or more",
+	"too_many_boxes":         "Box count exceeds the limit",
+	"name_no_letters":        "Recipient name contains only digits or symbols. Check that a phone or order number was not entered",
+	"invalid_number":         "must be a number",
+	"address_total_too_long": "Recipient address exceeds the total length limit",
+	"special_address":        "Post office hold and PO box addresses are skipped because carriers may reject them",
+	"phone_invalid":          "Phone number must have 10 or 11 digits including the area code",
+}
+
+// ruleMessagesEN 検証ルールから作るエラーの英語のメッセージ。エラーコードの接尾辞で引く
+var ruleMessagesEN = map[string]string{
+	"_required":       "%s is required",
+	"_too_long":       "%s must be at most %d full-width characters",
+	"_control_char":   "%s contains control characters such as newlines or tabs",
+	"_invalid_number": "%s must be a number",
+	"_invalid":        "%s must have 10 or 11 digits including the area code",
+}
+
+// sentinelMessagesEN 入力や処理結果のエラーの英語のメッセージ
+var sentinelMessagesEN = map[error]string{
+	ErrInputNotFound:    "file not found",
+	ErrInputParse:       "invalid CSV",
+	ErrInputJSON:        "invalid JSON",
+	ErrOrdersSkipped:    "some orders were skipped",
+	ErrConversionPanic:  "unexpected error while converting",
+	ErrUnencodableChars: "some characters cannot be represented in the output encoding",
+	ErrMixedCarriers:    "a file contains orders routed to another carrier",
+}
+
+// valueDetailEN 検証エラーに添えた値と文字数の英語の表示
+func valueDetailEN(ve *ValidationError) string {
+	if ve.Value == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (currently %d characters: '%s')", ve.Length, ve.excerpt())
+}
+
+// localizeError エラーをメッセージの言語で表示する文字列にする
+// 検証エラーはエラーコードで英語のメッセージを引き、ラップした詳細（「: 3」など）はそのまま残す
+// 文字数の超過の検証エラーには、Error()に含めない実際の値をここで添える
+func localizeError(err error) string {
+	if messageLocale != LocaleEN {
+		var ve *ValidationError
+		if errors.As(err, &ve) && ve.Value != "" {
+			return strings.Replace(err.Error(), ve.Error(), ve.Message+ve.valueDetail(), 1)
+		}
+		return err.Error()
+	}
+	for sentinel, msg := range sentinelMessagesEN {
+		if errors.Is(err, sentinel) && strings.HasPrefix(err.Error(), sentinel.Error()) {
+			return msg + strings.TrimPrefix(err.Error(), sentinel.Error())
+		}
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		return err.Error()
+	}
+	detail := valueDetailEN(ve) + strings.TrimPrefix(err.Error(), ve.Error())
+	if msg, ok := validationMessagesEN[ve.Code]; ok {
+		return msg + detail
+	}
+	for suffix, format := range ruleMessagesEN {
+		code, ok := strings.CutSuffix(ve.Code, suffix)
+		if !ok {
+			continue
+		}
+		label, ok := fieldLabelsEN[code]
+		if !ok {
+			break
+		}
+		if suffix == "_too_long" {
+			return fmt.Sprintf(format, label, ve.MaxLen) + detail
+		}
+		return fmt.Sprintf(format, label) + detail
+	}
+	return err.Error()
+}
+
+// localize メッセージの言語に応じて日本語か英語のメッセージを返す
+func localize(ja, en string) string {
+	if messageLocale == LocaleEN {
+		return en
+	}
+	return ja
+}
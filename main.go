@@ -1,78 +1,1281 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 	"unicode/utf8"
 
 	"github.com/gocarina/gocsv"
-	"golang.org/x/text/encoding/japanese"
-	"golang.org/x/text/transform"
+)
+
+// in Shopifyの注文データのCSV。複数回指定できる
+var in stringsFlag
+
+func init() {
+	flag.Var(&in, "in", "Shopifyの注文データのCSV（デフォルトはshopify-orders.csv）。.xlsxの場合は先頭のシートを読み込む。http(s)のURLを指定するとダウンロードする。複数回指定すると注文番号ごとにまとめて処理する。ファイル:内容品 の形式でファイルごとの内容品を指定できる。例: orders_tea.csv:お茶")
+}
+
+var (
+	timeout               = flag.Duration("timeout", 30*time.Second, "URLから注文データをダウンロードする際のタイムアウト")
+	previewJSON           = flag.Bool("preview-json", false, "CSVを出力せず、送り状と検証結果をJSONで標準出力に表示する")
+	singleFile            = flag.Bool("single-file", false, "チャンクごとにファイルを分けず、バッチ番号の列を付けて1つのファイルに出力する")
+	encoding              = flag.String("encoding", "", "出力するCSVの文字コード。sjis: 配送業者向け、utf8bom: Excel向け、utf8: Googleスプレッドシート向け。省略した場合は配送業者の文字コード（クリックポストはsjis）")
+	lineEnding            = flag.String("line-ending", "", "出力するCSVの改行コード。crlfかlf。省略した場合は配送業者の改行コード（クリックポストはcrlf）")
+	dedupeStreetFlag      = flag.Bool("dedupe-street", false, "Shipping StreetとShipping Address1の片方がもう片方に含まれる場合に、重ねずに住所2行目にする。例: 神南+神南1-2-3は神南1-2-3")
+	splitBuilding         = flag.Bool("split-building", false, "Shipping Address1に含まれる建物名・部屋番号を送り状の別の行に分ける")
+	companyFallback       = flag.Bool("company-fallback", true, "Shipping Nameが空欄でShipping Companyがある場合に、会社名を御中で宛名にする")
+	highValueThreshold    = flag.Float64("high-value-threshold", 0, "Totalがこの金額以上の注文は-high-value-contentsを内容品にする。0は無効")
+	contentsMapFile       = flag.String("contents-map", "", "Lineitem nameの商品名から内容品への対応付けを書いたJSONファイル。例: {\"サプリA\": \"サプリメント\"}。対応付けにない商品名は-contentsの内容品にする")
+	fuzzyContentsMap      = flag.Bool("fuzzy-contents-map", false, "-contents-mapの商品名を、全角・半角、空白、大文字・小文字の違いを無視して照合する")
+	highValueContents     = flag.String("high-value-contents", "", "高額注文の内容品。例: 健康食品（高額）")
+	honorificRulesFlag    = flag.String("honorific-rules", "", "氏名に含まれる文字列から敬称を決める規則。例: クリニック=御中,ギフト=お客様。会社名などのデフォルトの規則より先に照合する")
+	keepPlaceholders      = flag.Bool("keep-placeholders", false, "スキップした注文の位置に「【スキップ】注文番号」の行を残し、送り状の並びを注文の並びと揃える。この行はクリックポストにアップロードできない")
+	requireNameLetters    = flag.Bool("require-name-letters", false, "Shipping Nameが数字や記号だけの注文をスキップする。電話番号や注文番号を氏名の欄に入力した誤りを見つける")
+	maxAddressTotal       = flag.Int("max-address-total", 0, "住所1〜4行目の合計の文字数がこの値を超える注文をスキップする。各行は上限以内でも、町名や番地が複数の行に重複した住所を見つける。例: 60。0は無効")
+	defaultProvince       = flag.String("default-province", "", "Shipping Provinceが空欄の場合に使う都道府県。都道府県を入力させていないストア向けの最後の手段")
+	abbreviateAddress     = flag.Bool("abbreviate-address", false, "住所の行を略記して文字数を減らす。数字に挟まれた丁目・番地・番をハイフンにし、数字の後ろの号・号室を取り除き、マンションをMにする")
+	abbreviationsFile     = flag.String("abbreviations", "", "-abbreviate-addressで追加で置き換える文字列の対応付けを書いたJSONファイル。例: {\"ハイツ\": \"H\"}")
+	compactAddress        = flag.Bool("compact-address", true, "送り状の住所の空の行を詰めて、1・2行目から順に入れる。falseの場合はShipping Address2が空欄でも住所3行目を空けたままにする")
+	splitCareOf           = flag.Bool("split-care-of", false, "住所に含まれる「山田様方」のような気付の宛名を送り状の別の行に分ける")
+	notesLine             = flag.Bool("notes-line", false, "Notes列の注文メモを送り状の住所3・4行目の空いている行に入れる。空いている行がない場合は入れない")
+	splitContents         = flag.Bool("split-contents", false, "2箱以上の注文で内容品が長すぎる場合に、品目を箱ごとの送り状に分けて文字数に収める")
+	normalizeRoom         = flag.Bool("normalize-room", false, "Shipping Address2の「301」「Room 301」「Suite 800」「３０１号室」のような部屋番号を「301号室」の形にそろえる")
+	arabicNumerals        = flag.Bool("arabic-numerals", false, "住所の「三丁目五番二号」のような漢数字を「3丁目5番2号」にする。1〜99の丁目・番地・番・号だけを変換する")
+	stripHonorificFlag    = flag.Bool("strip-honorific", true, "Shipping Nameの末尾に入力された敬称（-honorificsの敬称）を取り除き、敬称の列と重複しないようにする")
+	honorificsFlag        = flag.String("honorifics", strings.Join(defaultHonorifics, ","), "-strip-honorificで取り除く敬称。カンマ区切りで、先に書いたものから照合する。例: 様,さま,サマ,殿,御中")
+	normalizeHyphens      = flag.Bool("normalize-hyphens", true, "郵便番号と電話番号の全角の数字と「－」「−」などのハイフンをASCIIに揃える")
+	controlChars          = flag.String("control-chars", "reject", "項目に改行やタブなどの制御文字がある場合の扱い。reject: スキップする、sanitize: 空白に置き換える")
+	mask                  = flag.Bool("mask", false, "プレビューとログに表示する氏名・郵便番号・住所の一部を伏せる。出力するCSVには影響しない")
+	debugBytes            = flag.Bool("debug-bytes", false, "先頭の注文を出力の文字コード（-encoding）で書き出したバイト列を16進ダンプで表示して終了する")
+	limit                 = flag.Int("limit", 0, "重複をまとめて絞り込んだ後の先頭のN件の注文だけを処理する。動作の確認用。0はすべて")
+	includeOrders         = flag.String("include-orders", "", "指定した注文番号の注文だけを処理する。カンマ区切り。「#」の有無は問わない")
+	excludeOrders         = flag.String("exclude-orders", "", "指定した注文番号の注文を処理しない。カンマ区切り。「#」の有無は問わない")
+	holdTags              = flag.String("hold-tags", "hold", "このタグが付いた注文を発送しない注文として処理しない。カンマ区切り。大文字・小文字は区別しない。空にすると無効")
+	stripOrderPrefix      = flag.Bool("strip-order-prefix", false, "ログに出力する注文番号の先頭の「#」を取り除く")
+	chunkMode             = flag.String("chunk-mode", string(ChunkModeGreedy), "注文データの分割方法。greedy: 上限まで詰める、balanced: 各ファイルの件数を均等にする")
+	zipDBFile             = flag.String("zip-db", "", "日本郵便の郵便番号データ（KEN_ALL.CSV）。指定すると空欄の都道府県・市区町村を郵便番号から補完する")
+	quiet                 = flag.Bool("quiet", false, "エラー以外のメッセージを出力しない。-verboseとは同時に指定できない")
+	verbose               = flag.Bool("verbose", false, "出力したファイルなどの詳細なメッセージも出力する")
+	parallel              = flag.Int("parallel", 1, "チャンクごとのファイルを並行して書き込む数")
+	stream                = flag.Bool("stream", false, "注文を1件ずつ変換し、ファイル1つ分の送り状がたまるたびに書き込む。注文が多くてもメモリの使用量が増えない")
+	showOrder             = flag.String("show-order", "", "指定した注文番号の注文だけを送り状に変換・検証し、送り状の各列と検証エラーを表示して終了する。「#」の有無は問わない")
+	explain               = flag.Bool("explain", false, "注文ごとに、送り状の各項目がどの入力項目からどう組み立てられたかを表示して終了する")
+	manifest              = flag.String("manifest", "", "エクスポートした注文の梱包リスト（注文番号・お届け先・内容品・箱数）を書き込むファイル")
+	writeDelay            = flag.Duration("write-delay", 0, "チャンクのファイルを書き終えてから次のファイルを書き始めるまで待つ時間。ネットワークの共有フォルダへの書き込みが詰まる場合に使う。例: 500ms。0は待たない")
+	sheetLayout           = flag.String("sheet-layout", "", "送り状を印刷する台紙の面の行数x列数。例: 5x2。-sheet-fillの順番で面に並ぶよう、ファイルごとに行を並べ替える")
+	sheetFill             = flag.String("sheet-fill", string(SheetFillColumns), "-sheet-layoutで面に並べたい順番。columns: 上から下へ、左の列から右の列へ、rows: 左から右へ、上の行から下の行へ（並べ替えない）")
+	splitBy               = flag.String("split-by", "", "指定した列の値ごとに注文を分け、値ごとのファイル（clickpost-shipping-labels-東京都-0.csvなど）に書き込む。Shopifyの列名か、郵便番号の上3桁のzip-prefixを指定する。空欄の注文は「その他」にまとめる")
+	fixList               = flag.String("fix-list", "", "スキップした注文ごとに、注文番号・問題の項目・現在の値・守られていないルール・直し方を書き込むCSV。例: fix-list.csv")
+	manifestOrderDate     = flag.Bool("manifest-order-date", false, "-manifestの梱包リストに、Created atから-timezoneの日付にした注文日の列を加える。送り状のCSVには出力しない")
+	timezone              = flag.String("timezone", "Asia/Tokyo", "ストアのタイムゾーン。-manifest-order-dateの注文日をこのタイムゾーンの日付にする")
+	checksums             = flag.String("checksums", "", "書き込んだ送り状のファイルごとのSHA-256と行数の一覧を書き込むファイル。アップロードまでにファイルが変わっていないか確かめる")
+	zipList               = flag.String("zip-list", "", "エクスポートした注文のお届け先郵便番号を、重複を除いて1行に1件ずつ書き込むファイル")
+	zipArchive            = flag.String("zip", "", "チャンクごとのファイルを個別に書き出さず、指定したzipファイルにまとめる")
+	noValidate            = flag.Bool("no-validate", false, "緊急時用。文字数などの検証をせず、必須の項目が空欄の注文と制御文字を含む注文のほかはそのまま送り状に書き込む。アップロードの前に手で直す必要がある")
+	strictEncoding        = flag.Bool("strict-encoding", false, "送り状に出力の文字コード（Shift-JIS）で表せない文字がある場合は、注文番号・列・文字をすべて表示し、ファイルを書き込まずに終了する")
+	retryEncoding         = flag.Bool("retry-encoding", false, "Shift-JISで表せない文字があるチャンクのファイルだけ、注意を表示してUTF-8で「-utf8」を付けた名前に書き込む")
+	logFile               = flag.String("log-file", "", "読み込んだファイル、スキップや修正した注文、書き込んだファイルを1行1つのJSONで書き足すログファイル")
+	lenientImportFlag     = flag.Bool("lenient-import", false, "注文データのCSVに引用符の誤りや列の数が異なる壊れた行がある場合に、その行を注意として表示して残りの行を読み込む。指定しない場合は読み込みを中止する")
+	fieldDefaultsFile     = flag.String("field-defaults", "", "送り状の項目が空欄の場合に入れる値のJSONファイル。キーはShippingAddress4のようなフィールド名。値は項目の文字数の上限などを満たす必要がある")
+	countByProvince       = flag.Bool("count-by-province", false, "書き込んだ送り状の枚数を都道府県ごとに、枚数の多い順に表示する。都道府県が分からない送り状は「不明」にまとめる")
+	contentsTemplate      = flag.String("contents-template", "", "注文ごとの内容品のtext/templateのテンプレート。例: {{.Name}}のご注文品。ShippingNameなどの項目と、商品名の一覧の.Lineitemsを使える。-contentsの代わりに使う")
+	specialAddress        = flag.String("special-address", string(SpecialAddressWarn), "局留めや私書箱の住所の注文の扱い。warn: 注意を表示する、reject: スキップする、separate: 別のファイル（clickpost-special-address-labels-0.csvなど）に書き込む、ignore: 確かめない")
+	specialAddressMarkers = flag.String("special-address-markers", strings.Join(defaultSpecialAddressMarkers, ","), "局留めや私書箱の住所とみなす目印。カンマ区切り。英字の大文字・小文字は区別しない")
+	inputFormat           = flag.String("in-format", string(InputFormatCSV), "-inの注文データの形式。csv、jsonのいずれか。jsonは注文の配列で、項目名はShippingNameのようなフィールド名")
+	inputEncoding         = flag.String("input-encoding", string(InputEncodingUTF8), "読み込むCSVの文字コード。utf8、sjis、autoのいずれか。autoはUTF-8として正しくない場合にShift-JISとみなす")
+	validateOnly          = flag.Bool("validate", false, "ファイルを書き込まず、送り状にできない注文と理由を表示して終了する")
+	count                 = flag.Bool("count", false, "エクスポートした場合に作られる送り状の枚数を表示して終了する。ファイルは書き込まない")
+	carriersFile          = flag.String("carriers", "", "複数の配送業者の検証ルールと送り状の列を定義したJSONファイル。-carrierで使う配送業者を選ぶ")
+	carrierName           = flag.String("carrier", "clickpost", "使う配送業者の名前。clickpost以外は-carriersのファイルに定義する")
+	weightThreshold       = flag.Float64("weight-threshold", 0, "Total Weightがこのグラム数を超える注文を-heavy-carrierの配送業者のファイルに振り分ける。0は無効")
+	heavyCarrier          = flag.String("heavy-carrier", "", "-weight-thresholdを超える重い注文に使う配送業者の名前。-carriersのファイルに定義する。例: yupack")
+	carrierTemplate       = flag.String("carrier-template", "", "送り状のCSVの列を定義したJSONファイル。指定しない場合はクリックポストの列で出力する")
+	correctionsFile       = flag.String("corrections", "", "注文番号と直した列を書いたCSV。Shopifyの注文データと同じ列名で、空欄ではない値で入力の同じ注文番号の注文を上書きする")
+	diffAgainst           = flag.String("diff-against", "", "前回ダウンロードしたShopifyの注文データのCSV。前回のCSVにない注文だけを処理する")
+	maxLen                = flag.String("max-len", "", "項目ごとの文字数の上限を上書きする。例: name=25,address1=30。項目はzip、name、address1〜address4、contents")
+	lenientLength         = flag.String("lenient-length", "", "文字数の上限を超えても送り状にし、警告だけにする項目。例: address1,address2。項目は-max-lenと同じ。必須の項目が空欄の場合はこれまでどおりスキップする")
+	reprocessFile         = flag.String("reprocess-file", "", "出力済みの送り状発行用CSVを読み込み、正規化と検証をやり直して同じファイルに書き直す。検証に通らない行は除く")
+	orderColumn           = flag.Bool("order-column", false, "送り状のCSVの最後に注文番号の列を追加する。余分な列を受け付けない配送業者では指定しない")
+	barcodeColumn         = flag.String("barcode-column", "", "送り状のCSVの最後に倉庫でバーコードにする値の列を追加する。{order}を注文番号（先頭の#なし）に置き換える。例: {order}、https://example.com/orders/{order}")
+	columnOrder           = flag.String("column-order", "", "クリックポストの送り状の列の順番をフィールド名で指定する。ShippingZip、ShippingName、ShippingNameTitle、ShippingAddress1〜ShippingAddress4、ShippingContentsをすべてカンマ区切りで並べる")
+	columnHeaders         = flag.String("column-headers", "", "送り状のCSVの列名をフィールド名ごとに書いたJSONファイル。例: {\"ShippingName\": \"お届け先名\"}。書かなかった列は今の列名のまま")
+	renameColumns         = flag.String("rename-columns", "", "クリックポストの送り状の列名を変える。例: お届け先敬称=敬称。カンマ区切りで複数指定できる")
+	chunkRange            = flag.String("chunk-range", "", "指定した番号のチャンクのファイルだけを書き込む。例: 3、2-4。番号はファイル名の番号と同じ")
+	appendFile            = flag.String("append", "", "出力済みの送り状発行用CSVに上限の40件まで追記する。入りきらない注文は続きの番号の新しいファイルに書き込む")
+	report                = flag.String("report", "", "送り状ごとの検証結果をHTMLの表として書き込むファイル")
+	locale                = flag.String("locale", string(LocaleJA), "コンソールに出力するメッセージの言語。jaかen。出力するCSVの内容は翻訳しない")
+	filenameStamp         = flag.String("filename-stamp", string(FilenameStampNone), "出力ファイル名に付ける識別子。none: 付けない、time: 実行した日時、random: ランダムな英数字。共有フォルダで複数人が実行してもファイルが上書きされないようにする")
+	showConfig            = flag.Bool("show-config", false, "入力、配送業者、文字コード、正規化の設定など、実際に使う設定を表示してから処理する")
+	stageDir              = flag.Bool("stage-dir", false, "出力するファイルを一時ディレクトリに書き込み、そのパスを表示する。確認してからアップロードするフォルダに移動する")
+	watchDir              = flag.String("watch", "", "フォルダを監視し、置かれたShopifyの注文データのCSVを順に変換する。変換した入力はarchive、変換できなかった入力はfailedのフォルダに移す")
+	watchOut              = flag.String("watch-out", "", "-watchで送り状を書き込むフォルダ。入力ごとに入力のファイル名のフォルダを作る。デフォルトは監視するフォルダのlabels")
+	watchInterval         = flag.Duration("watch-interval", 5*time.Second, "-watchでフォルダを確認する間隔。この間にサイズが変わらないファイルを書き込み済みとみなす")
+	explainSkips          = flag.Bool("explain-skips", false, "スキップした注文ごとに、理由に加えて直し方の提案を表示する")
+	maxFiles              = flag.Int("max-files", 10, "出力するCSVファイル数の上限。超える場合は何も書き込まずに終了する")
+	sample                = flag.Bool("sample", false, "入力用CSVのテンプレート（shopify-orders-sample.csv）を作成して終了する")
+	lint                  = flag.String("lint", "", "出力済みの送り状発行用CSVを指定すると、各行が文字数などの検証ルールを満たすか確かめ、すべての違反を行番号付きで表示して終了する")
+	diffPrevious          = flag.String("diff", "", "前回出力した送り状のCSV（カンマ区切りで複数指定可）を指定すると、今の設定で作る送り状と注文ごとに比べて表示し、ファイルは書き込まずに終了する")
+	describeMapping       = flag.Bool("describe-mapping", false, "送り状の項目ごとに、どのShopifyの項目からどう組み立てるかを今の設定で表示して終了する")
+	verify                = flag.String("verify", "", "出力済みの送り状発行用CSVを指定すると、アップロードできるか検証して終了する")
+	addressStyle          = flag.String("address-style", string(AddressStyleJP), "Shopifyの住所欄の書式。jp: 日本式、en: 英語式、single: 都道府県から建物名までをShipping Address1の1つの欄に入力")
+	warnOrderLimit        = flag.Bool("warn-order-limit", true, "注文がShopifyの1回のエクスポートの上限（50件）を超える場合に注意を表示する")
+	warnDuplicates        = flag.Bool("warn-duplicates", true, "同じ注文番号で配送先まで同じ内容の行がある注文を警告する")
+	warnOverseas          = flag.Bool("warn-overseas", true, "郵便番号や都道府県が日本の形式ではない注文を海外注文の可能性として警告する")
+	warnChunkCount        = flag.Int("warn-chunk-count", 2, "出力するファイルがこの数を超える場合に、入力の重複を確認するよう注意を表示する。0は無効")
+	warnSwappedFields     = flag.Bool("warn-swapped-fields", false, "氏名が住所のような注文や、住所が氏名のような注文を、取り違えの可能性として警告する")
+	warnSharedAddress     = flag.Bool("warn-shared-address", false, "住所が同じで氏名が異なる注文を警告する")
+	contents              = flag.String("contents", strings.Join(defaultContents, ","), "内容品。複数の品目はカンマ区切りで指定する")
+	namePrefix            = flag.String("name-prefix", "", "お届け先氏名の前に付ける文字列")
+	nameSuffix            = flag.String("name-suffix", "", "お届け先氏名の後ろに付ける文字列")
+	truncateName          = flag.Bool("truncate-name", false, "お届け先氏名が上限の文字数を超える注文をスキップせず、目印を含めて上限に収まるよう末尾を切り詰める")
+	nameTruncationMarker  = flag.String("name-truncation-marker", "", "-truncate-nameで切り詰めた氏名の末尾に付ける目印。省略した場合は配送業者の目印（デフォルトは「…」）")
+)
+
+// クリックポストにアップロードできる送り状ラベルは最大40件まで
+const maxClickpostShippingLabels = 40
+
+// Shopifyの1回のエクスポートに含まれる注文は最大50件まで
+const shopifyExportLimit = 50
+
+// 終了コード。監視で「正常」「要確認」「失敗」を区別できるようにする
+const (
+	exitOK      = 0 // すべての注文をエクスポートした
+	exitFatal   = 1 // 入力の不備や書き込みの失敗で処理できなかった
+	exitSkipped = 2 // エクスポートしたが、スキップした注文がある
 )
 
 func main() {
+	if err := parseArgs(os.Args[1:]); err != nil {
+		// -hでヘルプを表示した場合は正常終了にする
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(exitOK)
+		}
+		os.Exit(exitCode(err))
+	}
+	err := run()
+	auditLog.Finish(err)
+	os.Exit(exitCode(err))
+}
+
+// exitCode runの結果から終了コードを決める。スキップした注文はrunの中でログに出力済みなので、ここでは表示しない
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, ErrOrdersSkipped) {
+		return exitSkipped
+	}
+	fmt.Fprintf(os.Stderr, localize("エラー: %s\n", "error: %s\n"), localizeError(err))
+	return exitFatal
+}
+
+func run() error {
+	if *quiet && *verbose {
+		return errors.New("-quietと-verboseは同時に指定できません")
+	}
+	quietOutput, verboseOutput, maskOutput = *quiet, *verbose, *mask
+	lenientImport = *lenientImportFlag
+	l, err := ParseLocale(*locale)
+	if err != nil {
+		return err
+	}
+	messageLocale = l
+	if *logFile != "" {
+		if auditLog, err = OpenAuditLog(*logFile); err != nil {
+			return err
+		}
+		auditLog.Log(logLevelInfo, "", "start", strings.Join(os.Args[1:], " "))
+	}
+	if *verify != "" {
+		// 変換と同じ配送業者と文字数の上限の上書きで確かめる
+		_, _, carrier, err := configuredCarrier()
+		if err != nil {
+			return err
+		}
+		return runVerify(*verify, carrier)
+	}
+	if *lint != "" {
+		_, _, carrier, err := configuredCarrier()
+		if err != nil {
+			return err
+		}
+		return runLint(*lint, carrier)
+	}
+	if *sample {
+		const filename = "shopify-orders-sample.csv"
+		if err := WriteSampleShopifyOrders(filename); err != nil {
+			return err
+		}
+		infof("%sを作成しました\n", filename)
+		return nil
+	}
+	if *watchDir != "" {
+		return runWatch(*watchDir, *watchOut, *watchInterval)
+	}
+	return convert(in, "")
+}
+
+// configuredCarrier -carriersと-carrierで配送業者を選び、-max-lenと-lenient-lengthで上書きした検証ルールの定義を返す
+// 選んだ配送業者の定義は変更しないので、-watchで繰り返し呼んでも上書きが重ならない
+func configuredCarrier() (map[string]*CarrierConfig, *CarrierConfig, *Carrier, error) {
+	var carriers map[string]*CarrierConfig
+	if *carriersFile != "" {
+		var err error
+		if carriers, err = LoadCarriers(*carriersFile); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	selected, err := SelectCarrier(carriers, *carrierName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	carrier := selected.Carrier
+	if *maxLen != "" {
+		overrides, err := ParseMaxLenOverrides(*maxLen)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var warnings []string
+		if carrier, warnings, err = carrier.WithMaxLen(overrides); err != nil {
+			return nil, nil, nil, err
+		}
+		for _, w := range warnings {
+			warnf("注意: %s\n", w)
+		}
+	}
+	if *lenientLength != "" {
+		if carrier, err = carrier.WithLenientLength(ParseLenientLength(*lenientLength)); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return carriers, selected, carrier, nil
+}
+
+// convert 注文データを読み込み、送り状のファイルに変換する
+// outDirを指定した場合は、出力するファイルをそのフォルダに書き込む
+func convert(in stringsFlag, outDir string) error {
+	style, err := ParseAddressStyle(*addressStyle)
+	if err != nil {
+		return err
+	}
+	honorificRules, err := ParseHonorificRules(*honorificRulesFlag)
+	if err != nil {
+		return err
+	}
+	honorifics, err := ParseHonorifics(*honorificsFlag)
+	if err != nil {
+		return err
+	}
+	specialMode, err := ParseSpecialAddressMode(*specialAddress)
+	if err != nil {
+		return err
+	}
+	specialMarkers, err := ParseSpecialAddressMarkers(*specialAddressMarkers)
+	if err != nil {
+		return err
+	}
+	opts := ConvertOptions{
+		AddressStyle:          style,
+		NamePrefix:            *namePrefix,
+		NameSuffix:            *nameSuffix,
+		TruncateName:          *truncateName,
+		NameTruncationMarker:  *nameTruncationMarker,
+		Contents:              ParseContents(*contents),
+		SplitBuilding:         *splitBuilding,
+		DedupeStreet:          *dedupeStreetFlag,
+		CompanyFallback:       *companyFallback,
+		ArabicNumerals:        *arabicNumerals,
+		NormalizeRoom:         *normalizeRoom,
+		SplitContents:         *splitContents,
+		NotesLine:             *notesLine,
+		SplitCareOf:           *splitCareOf,
+		CompactAddress:        *compactAddress,
+		AbbreviateAddress:     *abbreviateAddress,
+		BarcodeTemplate:       *barcodeColumn,
+		DefaultProvince:       *defaultProvince,
+		RequireNameLetters:    *requireNameLetters,
+		MaxAddressTotal:       *maxAddressTotal,
+		KeepPlaceholders:      *keepPlaceholders,
+		HonorificRules:        honorificRules,
+		HighValueThreshold:    *highValueThreshold,
+		HighValueContents:     *highValueContents,
+		FuzzyContentsMap:      *fuzzyContentsMap,
+		StripHonorific:        *stripHonorificFlag,
+		Honorifics:            honorifics,
+		SanitizeControlChars:  *controlChars == "sanitize",
+		NormalizeHyphens:      *normalizeHyphens,
+		RejectSpecialAddress:  specialMode == SpecialAddressReject,
+		SpecialAddressMarkers: specialMarkers,
+		NoValidate:            *noValidate,
+	}
+	if *contentsMapFile != "" {
+		if opts.ContentsMap, err = LoadContentsMap(*contentsMapFile); err != nil {
+			return err
+		}
+	}
+	if *abbreviationsFile != "" {
+		if opts.Abbreviations, err = LoadAddressAbbreviations(*abbreviationsFile); err != nil {
+			return err
+		}
+	}
+	if *contentsTemplate != "" {
+		if opts.ContentsTemplate, err = ParseContentsTemplate(*contentsTemplate); err != nil {
+			return err
+		}
+	}
+	if *fieldDefaultsFile != "" {
+		if opts.FieldDefaults, err = LoadFieldDefaults(*fieldDefaultsFile); err != nil {
+			return err
+		}
+	}
+	carriers, selected, carrier, err := configuredCarrier()
+	if err != nil {
+		return err
+	}
+	opts.Carrier = carrier
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if *controlChars != "reject" && *controlChars != "sanitize" {
+		return fmt.Errorf("-control-charsはrejectかsanitizeを指定してください: %s", *controlChars)
+	}
+	// 文字コードと改行コードは、指定がなければ配送業者のアップロードの仕様に合わせる
+	eopts, err := carrier.exportOptions(*encoding, *lineEnding)
+	if err != nil {
+		return err
+	}
+	eopts.Carrier = carrier
+	if *retryEncoding {
+		if *strictEncoding || *singleFile || *zipArchive != "" || *appendFile != "" {
+			return errors.New("-retry-encodingは-strict-encoding、-single-file、-zip、-appendと同時に指定できません")
+		}
+		eopts.RetryEncoding = true
+	}
+	if *reprocessFile != "" {
+		return runReprocess(*reprocessFile, opts, eopts)
+	}
+	if *describeMapping {
+		DescribeMapping(os.Stdout, opts)
+		return nil
+	}
+	if selected.Template != nil {
+		if *carrierTemplate != "" || *renameColumns != "" || *columnHeaders != "" || *columnOrder != "" || *orderColumn || *barcodeColumn != "" || *singleFile {
+			return fmt.Errorf("%sは-carriersで列を定義しているため、-carrier-template、-rename-columns、-column-headers、-column-order、-order-column、-barcode-column、-single-fileと同時に指定できません", *carrierName)
+		}
+		eopts.Template = selected.Template
+	}
+	if *carrierTemplate != "" && (*renameColumns != "" || *columnHeaders != "" || *columnOrder != "") {
+		return errors.New("-carrier-templateと-rename-columns、-column-headers、-column-orderは同時に指定できません")
+	}
+	if *renameColumns != "" && *columnHeaders != "" {
+		return errors.New("-rename-columnsと-column-headersは同時に指定できません")
+	}
+	if *carrierTemplate != "" {
+		if *singleFile {
+			return errors.New("-carrier-templateと-single-fileは同時に指定できません")
+		}
+		if eopts.Template, err = LoadCarrierTemplate(*carrierTemplate); err != nil {
+			return err
+		}
+	}
+	if *carrierTemplate != "" && *orderColumn {
+		return errors.New("-carrier-templateと-order-columnは同時に指定できません。列の定義にOrderNameの列を追加してください")
+	}
+	// -carrier-templateでは、列の定義のBarcodeの列に-barcode-columnの値を出力する
+	if *renameColumns != "" || *columnHeaders != "" || *columnOrder != "" || *orderColumn || (*barcodeColumn != "" && *carrierTemplate == "") {
+		if *singleFile {
+			return errors.New("-rename-columns、-column-headers、-column-order、-order-column、-barcode-columnと-single-fileは同時に指定できません")
+		}
+		renames, err := ParseColumnRenames(*renameColumns)
+		if err != nil {
+			return err
+		}
+		if *columnHeaders != "" {
+			if renames, err = LoadColumnHeaders(*columnHeaders); err != nil {
+				return err
+			}
+		}
+		if eopts.Template, err = ClickpostTemplate(renames); err != nil {
+			return err
+		}
+		if *columnOrder != "" {
+			if err := eopts.Template.ReorderColumns(ParseColumnOrder(*columnOrder)); err != nil {
+				return err
+			}
+		}
+		if *orderColumn {
+			// クリックポストは余分な列を無視し、管理画面のプレビューには表示される
+			eopts.Template.Columns = append(eopts.Template.Columns, TemplateColumn{Header: "注文番号", Field: "OrderName"})
+		}
+		if *barcodeColumn != "" {
+			eopts.Template.Columns = append(eopts.Template.Columns, TemplateColumn{Header: "バーコード", Field: "Barcode"})
+		}
+	}
+	ienc, err := ParseInputEncoding(*inputEncoding)
+	if err != nil {
+		return err
+	}
+	format, err := ParseInputFormat(*inputFormat)
+	if err != nil {
+		return err
+	}
+	// プレビューなど、ファイルを書き込まないモードでは確認しない
+	// -stage-dirの場合は、本番と同じファイルを一時ディレクトリに書き込む
+	if !*previewJSON && *diffPrevious == "" && !*debugBytes && !*explain && !*count && !*validateOnly && *showOrder == "" {
+		if *stageDir {
+			if *appendFile != "" {
+				return errors.New("-stage-dirと-appendは同時に指定できません")
+			}
+			if outDir, err = os.MkdirTemp("", "shopify-shipping-csv-"); err != nil {
+				return fmt.Errorf("一時ディレクトリを作成できません: %w", err)
+			}
+		} else if err := checkWritable(outputPath(outDir, ".")); err != nil {
+			return err
+		}
+	}
 	// Shopifyの注文データは最大50件
-	orders, err := ImportShopifyOrders("shopify-orders.csv")
+	if len(in) == 0 {
+		in = stringsFlag{"shopify-orders.csv"}
+	}
+	// 標準出力はプレビューのJSONなどに使うので、設定は標準エラー出力に表示する
+	if *showConfig {
+		WriteResolvedConfig(os.Stderr, ResolvedConfig(in, ienc, opts, eopts, outDir))
+	}
+	var orders []*ShopifyOrder
+	for _, src := range in {
+		src, fileContents := splitInputContents(src)
+		if err := validateFileContents(src, fileContents, carrier); err != nil {
+			return err
+		}
+		var imported []*ShopifyOrder
+		if format == InputFormatJSON {
+			if isURL(src) {
+				return fmt.Errorf("-in-format jsonの場合はURLを指定できません: %s", src)
+			}
+			imported, err = ImportShopifyOrdersJSON(src)
+		} else if isURL(src) {
+			imported, err = FetchShopifyOrders(src, *timeout, ienc)
+		} else {
+			imported, err = ImportShopifyOrders(src, ienc)
+		}
+		if err != nil {
+			return err
+		}
+		auditLog.Log(logLevelInfo, "", "import", fmt.Sprintf("%s: %d件", src, len(imported)))
+		for _, o := range imported {
+			o.DefaultContents = fileContents
+		}
+		orders = append(orders, imported...)
+	}
+	if *warnDuplicates {
+		for _, name := range FindDuplicateOrders(orders) {
+			warnf("注意: 注文%sに同じ内容の行が複数あります。同じエクスポートを重ねて読み込んでいないか確認してください\n", name)
+		}
+	}
+	reconciliation := Reconciliation{Input: len(orders)}
+	orders = MergeShopifyOrders(orders)
+	reconciliation.Merged = len(orders)
+	if *correctionsFile != "" {
+		corrections, err := ImportShopifyOrders(*correctionsFile, ienc)
+		if err != nil {
+			return err
+		}
+		corrected, unmatched := ApplyCorrections(orders, corrections)
+		infof("修正のCSVで%d件の注文を上書きしました\n", corrected)
+		for _, c := range corrections {
+			if !slices.Contains(unmatched, c.Name) {
+				auditLog.Log(logLevelInfo, c.Name, "correction", *correctionsFile)
+			}
+		}
+		for _, name := range unmatched {
+			warnf("注意: 修正のCSVの注文%sは入力にありません\n", name)
+			auditLog.Log(logLevelWarn, name, "correction_unmatched", *correctionsFile)
+		}
+	}
+	if *warnOrderLimit && len(orders) > shopifyExportLimit {
+		warnf("注意: 注文が%d件あります。Shopifyの1回のエクスポートは%d件までなので、ファイルの結合の誤りや重複がないか確認してください\n", len(orders), shopifyExportLimit)
+	}
+	if *diffAgainst != "" {
+		// 1日に2回ダウンロードした場合に、前回印刷した送り状を出し直さない
+		previous, err := ImportShopifyOrders(*diffAgainst, ienc)
+		if err != nil {
+			return err
+		}
+		before := len(orders)
+		orders = FilterOrders(orders, nil, OrderNameSet(previous))
+		infof("前回のエクスポートにある%d件の注文をスキップしました\n", before-len(orders))
+	}
+	if *zipDBFile != "" {
+		db, err := LoadZipDB(*zipDBFile)
+		if err != nil {
+			return err
+		}
+		for _, w := range CompleteAddresses(orders, db) {
+			warnf("注意: %s\n", w)
+		}
+	}
+	// -include-ordersなどで処理しない注文も確かめられるよう、絞り込む前に探す
+	if *showOrder != "" {
+		found := FilterOrders(orders, ParseOrderNames(*showOrder), nil)
+		if len(found) == 0 {
+			return fmt.Errorf("%w: %s", ErrOrderNotFound, *showOrder)
+		}
+		var invalid int
+		for _, o := range found {
+			ok, err := ShowOrder(os.Stdout, o, opts, eopts, *mask)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				invalid++
+			}
+		}
+		if _, held := HoldOrders(found, ParseTags(*holdTags)); len(held) > 0 {
+			warnf("注意: 保留のタグ（%s）が付いているため、変換では処理しない注文: %s\n", *holdTags, formatOrderNames(held, *mask))
+		}
+		if invalid > 0 {
+			return fmt.Errorf("%w: %d件", ErrOrdersSkipped, invalid)
+		}
+		return nil
+	}
+	orders = FilterOrders(orders, ParseOrderNames(*includeOrders), ParseOrderNames(*excludeOrders))
+	orders, held := HoldOrders(orders, ParseTags(*holdTags))
+	if len(held) > 0 {
+		warnf("注意: 保留のタグ（%s）が付いた%d件の注文を処理しません: %s\n", *holdTags, len(held), formatOrderNames(held, *mask))
+	}
+	SortByPrintOrder(orders)
+	// 動作の確認用に先頭の注文だけを処理する。注文の件数の上限とは異なり、エラーにはしない
+	if *limit < 0 {
+		return fmt.Errorf("-limitは0以上を指定してください: %d", *limit)
+	}
+	if *limit > 0 && len(orders) > *limit {
+		debugf("-limit: %d件のうち先頭の%d件を処理します\n", len(orders), *limit)
+		orders = orders[:*limit]
+	}
+	reconciliation.Filtered = len(orders)
+	if abbreviated := AbbreviatedOrders(orders, opts); len(abbreviated) > 0 {
+		warnf("注意: 住所を略記した注文: %s\n", formatOrderNames(abbreviated, *mask))
+	}
+	for _, name := range UnmatchedLineitemNames(orders, opts) {
+		warnf("注意: 内容品の対応付けにない商品名のため、通常の内容品にします: %s\n", name)
+	}
+	// -weight-thresholdの場合は、重い注文に使う配送業者。-countでもエクスポートと同じ配送業者で数える
+	var heavyConfig *CarrierConfig
+	if *weightThreshold > 0 {
+		if *heavyCarrier == "" {
+			return errors.New("-weight-thresholdを指定する場合は-heavy-carrierも指定してください")
+		}
+		if heavyConfig, err = SelectCarrier(carriers, *heavyCarrier); err != nil {
+			return err
+		}
+		if heavyConfig.Carrier.Name == carrier.Name {
+			return fmt.Errorf("-heavy-carrierには%s以外の配送業者を指定してください", carrier.Name)
+		}
+	}
+	if *count {
+		fmt.Println(EstimateLabelCount(orders, opts, *weightThreshold, heavyConfig))
+		return nil
+	}
+	if *validateOnly {
+		valid, rejects := SelectValidOrders(orders, opts)
+		logRejectedOrders(rejects, *stripOrderPrefix, skipSuggester(opts))
+		infof(localize("%d件の注文を送り状にできます\n", "%d orders can be exported\n"), len(valid))
+		if len(rejects) > 0 {
+			return fmt.Errorf("%w: %d件", ErrOrdersSkipped, len(rejects))
+		}
+		return nil
+	}
+	if *explain {
+		for _, o := range orders {
+			ExplainOrder(os.Stdout, o, opts)
+		}
+		return nil
+	}
+	if *debugBytes {
+		return DebugShiftJISBytes(os.Stdout, orders, opts, eopts)
+	}
+	if *previewJSON {
+		previews := BuildPreviewLabels(orders, opts)
+		if *mask {
+			MaskPreviewLabels(previews)
+		}
+		b, err := MarshalPreviewJSON(previews)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	if *diffPrevious != "" {
+		var filenames []string
+		for _, f := range strings.Split(*diffPrevious, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				filenames = append(filenames, f)
+			}
+		}
+		labels, _ := BuildClickpostShippingLabels(orders, opts)
+		diff, err := DiffExport(filenames, labels, eopts)
+		if err != nil {
+			return err
+		}
+		PrintExportDiff(os.Stdout, diff, *mask)
+		return nil
+	}
+	if *warnOverseas {
+		for _, o := range FindOverseasOrders(orders) {
+			warnf("注意: 海外注文の可能性があります: %s（%s）\n", formatOrderNames([]*ShopifyOrder{o.Order}, *mask), o.Reason)
+		}
+	}
+	if *warnSwappedFields {
+		for _, o := range FindSwappedFields(orders) {
+			warnf("注意: 氏名と住所を取り違えている可能性があります: %s（%s）\n", formatOrderNames([]*ShopifyOrder{o.Order}, *mask), o.Reason)
+		}
+	}
+	if specialMode == SpecialAddressWarn {
+		for _, o := range FindSpecialAddressOrders(orders, opts) {
+			warnf("注意: 局留めや私書箱の住所は配送業者が受け付けないことがあります: %s（「%s」）\n", formatOrderNames([]*ShopifyOrder{o.Order}, *mask), o.Marker)
+		}
+	}
+	if opts.NoValidate {
+		warnf("警告: -no-validateにより送り状を検証していません。%d枚の送り状は検証に通らないため、アップロードの前に手で直してください\n", CountUnvalidatedLabels(orders, opts))
+	}
+	for _, v := range FindLengthViolations(orders, opts) {
+		warnf("注意: 注文番号:%s %sは全角%d文字までですが、%d文字のまま書き込みます\n", v.OrderName, v.Label, v.MaxLen, v.Length)
+	}
+	if found := FindStreetDuplicates(orders, opts); len(found) > 0 {
+		infof("Shipping StreetとShipping Address1の重複を除いた注文: %s\n", formatOrderNames(found, *mask))
+	}
+	if *warnSharedAddress {
+		for _, group := range FindSharedAddresses(orders, opts) {
+			warnf("注意: 同じ住所に氏名の異なる注文があります: %s\n", formatOrderNames(group, *mask))
+		}
+	}
+	if *report != "" {
+		previews := BuildPreviewLabels(orders, opts)
+		if *mask {
+			MaskPreviewLabels(previews)
+		}
+		if err := WriteValidationReport(outputPath(outDir, *report), previews); err != nil {
+			return err
+		}
+	}
+	mode, err := ParseChunkMode(*chunkMode)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	stamp, err := ParseFilenameStamp(*filenameStamp)
+	if err != nil {
+		return err
+	}
+	// 重い注文などを振り分ける前に、すべての注文の注文日を控えておく
+	var orderDates map[string]string
+	if *manifestOrderDate {
+		if *manifest == "" {
+			return errors.New("-manifest-order-dateを指定する場合は-manifestも指定してください")
+		}
+		loc, err := LoadStoreLocation(*timezone)
+		if err != nil {
+			return err
+		}
+		orderDates = OrderDates(orders, loc)
+	}
+	if stamp != FilenameStampNone && *appendFile != "" {
+		return errors.New("-filename-stampと-appendは同時に指定できません")
+	}
+	if specialMode == SpecialAddressSeparate && (*singleFile || *zipArchive != "" || *appendFile != "" || *chunkRange != "" || *stream) {
+		return errors.New("-special-address separateは-single-file、-zip、-append、-chunk-range、-streamと同時に指定できません")
 	}
-	// クリックポストにアップロードできる送り状ラベルは最大40件まで
-	const maxClickpostShippingLabels = 40
-	for i, chunkedOrders := range ChunkShopifyOrders(orders, maxClickpostShippingLabels) {
-		if err := ExportClickpostShippingLabels(fmt.Sprintf("clickpost-shipping-labels-%d.csv", i), chunkedOrders); err != nil {
-			panic(err)
+	if *stream {
+		if mode != ChunkModeGreedy || *parallel > 1 {
+			return errors.New("-streamは-chunk-mode balancedや2以上の-parallelと同時に指定できません")
 		}
+		if *singleFile || *zipArchive != "" || *appendFile != "" || *chunkRange != "" || *weightThreshold > 0 || *manifest != "" || *zipList != "" || *countByProvince {
+			return errors.New("-streamは-single-file、-zip、-append、-chunk-range、-weight-threshold、-manifest、-zip-list、-count-by-provinceと同時に指定できません")
+		}
+	}
+	if *writeDelay < 0 {
+		return fmt.Errorf("-write-delayは0以上を指定してください: %s", *writeDelay)
+	}
+	if *writeDelay > 0 && *parallel > 1 {
+		return errors.New("-write-delayと2以上の-parallelは同時に指定できません")
 	}
+	eopts.WriteDelay = *writeDelay
+	if *sheetLayout != "" {
+		if *singleFile || *appendFile != "" {
+			return errors.New("-sheet-layoutは-single-file、-appendと同時に指定できません")
+		}
+		if eopts.SheetLayout, err = ParseSheetLayout(*sheetLayout, *sheetFill); err != nil {
+			return err
+		}
+	}
+	if *maxFiles < 1 {
+		return fmt.Errorf("-max-filesは1以上を指定してください: %d", *maxFiles)
+	}
+	if *parallel < 1 {
+		return fmt.Errorf("-parallelは1以上を指定してください: %d", *parallel)
+	}
+	if *zipArchive != "" && *singleFile {
+		return fmt.Errorf("-zipと-single-fileは同時に指定できません")
+	}
+	filenameFormat, singleFilename, err := stamp.clickpostFilenames()
+	if err != nil {
+		return err
+	}
+	var exported []*ClickpostShippingLabel
+	// -streamで書き込んだ送り状の枚数。送り状をexportedに残さないので別に数える
+	var streamed int
+	// 書き込んだ送り状のファイル。-checksumsの一覧に使う
+	var outputs []OutputFile
+	// 送り状にできない注文がチャンクの枠を使わないよう、分割の前にスキップする
+	// これで各ファイルには検証に通った送り状が上限まで入る
+	// -keep-placeholdersの場合は、スキップした注文も代わりの行として枠を使う
+	// -weight-thresholdの場合は、重い注文を先に別の配送業者のファイルにエクスポートし、軽い注文を続けて処理する
+	var heavy []*ShopifyOrder
+	// 注文ごとの配送業者。振り分けない場合はnil
+	var route CarrierRoute
+	if heavyConfig != nil {
+		if *singleFile || *zipArchive != "" || *appendFile != "" || *chunkRange != "" {
+			return errors.New("-weight-thresholdは-single-file、-zip、-append、-chunk-rangeと同時に指定できません")
+		}
+		var unknown []*ShopifyOrder
+		orders, heavy, unknown = SplitOrdersByWeight(orders, *weightThreshold)
+		route = WeightRoute(*weightThreshold, carrier.Name, heavyConfig.Carrier.Name)
+		if len(unknown) > 0 {
+			warnf("注意: Total Weightが空欄か数値ではないため%sにする注文: %s\n", carrier.Name, formatOrderNames(unknown, *mask))
+		}
+		infof("%s: %d件、%s: %d件\n", carrier.Name, len(orders), heavyConfig.Carrier.Name, len(heavy))
+	}
+	var rejects []*RejectedOrder
+	// -streamは書き込みながら検証するので、先にすべての注文を変換しない
+	if !opts.KeepPlaceholders && !*stream {
+		orders, rejects = SelectValidOrders(orders, opts)
+	}
+	// 局留めや私書箱の住所の注文は、検証に通ったものだけを別のファイルに分ける
+	var special []*ShopifyOrder
+	if specialMode == SpecialAddressSeparate {
+		orders, special = SplitSpecialAddressOrders(orders, opts)
+	}
+	// スキップした注文は最後に注文番号順でまとめて出力する
+	// すべてのファイルを書き込めた場合は、その後に段階ごとの件数を出力する
+	var reconciled bool
+	defer func() {
+		logRejectedOrders(rejects, *stripOrderPrefix, skipSuggester(opts))
+		if reconciled {
+			reconciliation.Labels, reconciliation.Skipped = len(exported)+streamed, len(rejects)
+			infof("%s\n", reconciliation)
+			if *countByProvince && len(exported) > 0 {
+				infof("%s\n", localize("都道府県ごとの送り状:", "Labels by prefecture:"))
+				for _, c := range CountLabelsByProvince(exported) {
+					infof("  %s: %d件\n", c.Province, c.Labels)
+				}
+			}
+		}
+	}()
+	// 重い注文のファイルも含め、どのファイルも書き込む前に確かめる
+	if *strictEncoding {
+		if err := checkEncodable(orders, opts, eopts); err != nil {
+			return err
+		}
+		if err := checkEncodable(special, opts, eopts); err != nil {
+			return err
+		}
+	}
+	// 重い注文と局留めや私書箱の住所の注文は、ここではファイル数を確かめるだけにして、すべての確認が終わってから書き込む
+	var heavyChunks, specialChunks [][]*ShopifyOrder
+	var heavyEopts ExportOptions
+	if len(heavy) > 0 {
+		if heavyEopts, err = heavyConfig.Carrier.exportOptions(*encoding, *lineEnding); err != nil {
+			return err
+		}
+		heavyEopts.RetryEncoding, heavyEopts.SheetLayout, heavyEopts.WriteDelay = eopts.RetryEncoding, eopts.SheetLayout, eopts.WriteDelay
+		if *strictEncoding {
+			if err := checkEncodable(heavy, opts, heavyEopts); err != nil {
+				return err
+			}
+		}
+		c, r, err := PlanCarrierChunks(heavy, heavyConfig, route, mode, *maxFiles, opts)
+		rejects = append(rejects, r...)
+		if err != nil {
+			return err
+		}
+		heavyChunks = c
+	}
+	if len(special) > 0 {
+		specialChunks = ChunkShopifyOrdersBy(mode, special, carrier.MaxLabels)
+		if len(specialChunks) > *maxFiles {
+			return fmt.Errorf("局留めや私書箱の住所の出力ファイル数が上限を超えています。%d件のファイルが作成されます（上限%d件）", len(specialChunks), *maxFiles)
+		}
+	}
+	var offset int
+	// -appendで既存のファイルに追記する注文
+	var appendOrders []*ShopifyOrder
+	if *appendFile != "" {
+		if *singleFile || *chunkRange != "" || (eopts.Encoding != "" && eopts.Encoding != EncodingShiftJIS) {
+			return errors.New("-appendは-single-file、-chunk-range、Shift-JIS以外の-encodingと同時に指定できません")
+		}
+		// 既存のファイルは、出力ファイル数などをすべて確かめてから書き換える
+		if appendOrders, orders, err = PlanAppend(*appendFile, orders, eopts); err != nil {
+			return err
+		}
+		offset = nextFreeChunkIndex(outputPath(outDir, filenameFormat))
+	}
+	chunks := ChunkShopifyOrdersBy(mode, orders, carrier.MaxLabels)
+	// -split-byの場合は、グループごとに分割する。件数の確認には、すべてのグループのチャンクをつないだものを使う
+	var groupChunks [][][]*ShopifyOrder
+	var groupFormats []string
+	if *splitBy != "" {
+		if *singleFile || *zipArchive != "" || *appendFile != "" || *chunkRange != "" || *stream {
+			return errors.New("-split-byは-single-file、-zip、-append、-chunk-range、-streamと同時に指定できません")
+		}
+		key, err := ParseSplitBy(*splitBy)
+		if err != nil {
+			return err
+		}
+		groups := GroupOrdersBy(orders, key)
+		if groupFormats, err = groupFilenameFormats(filenameFormat, groups); err != nil {
+			return err
+		}
+		chunks = nil
+		for _, g := range groups {
+			c := ChunkShopifyOrdersBy(mode, g.Orders, carrier.MaxLabels)
+			groupChunks = append(groupChunks, c)
+			chunks = append(chunks, c...)
+		}
+	}
+	if offset > 0 {
+		// 既存のファイルの番号は空のチャンクにして、入りきらなかった注文を続きの番号のファイルに書き込む
+		chunks = append(make([][]*ShopifyOrder, offset), chunks...)
+	}
+	if *chunkRange != "" {
+		// 番号がずれないよう、すべての注文を分割してから範囲外のチャンクを空にする
+		from, to, err := ParseChunkRange(*chunkRange, len(chunks))
+		if err != nil {
+			return err
+		}
+		for i := range chunks {
+			if i < from || i > to {
+				chunks[i] = nil
+			}
+		}
+	}
+	if err := CheckChunkCarriers(chunks, route, carrier.Name); err != nil {
+		return err
+	}
+	// -streamのファイル数は書き込みながら確かめる
+	if n := len(chunks) - offset; !*stream && n > *maxFiles {
+		return fmt.Errorf("出力ファイル数が上限を超えています。%d件のファイルが作成されます（上限%d件）", n, *maxFiles)
+	}
+	// Shopifyの1回のエクスポートは50件なので、ふだんは1〜2ファイルに収まる。それより多い場合は同じエクスポートを重ねて読み込んだことが多い
+	// -split-byのグループごとのファイルは重複によらず増えるので数えない
+	if n := len(chunks) - offset; !*stream && groupChunks == nil && *warnChunkCount > 0 && n > *warnChunkCount {
+		warnf("注意: %d件のファイルに分かれます（目安は%d件まで）。同じ注文データを重ねて読み込んでいないか確認してください\n", n, *warnChunkCount)
+	}
+	// collect 別のファイルに分けた注文の書き込みの結果をまとめる
+	collect := func(results []*ChunkResult) error {
+		for _, result := range results {
+			rejects = append(rejects, result.Rejects...)
+			if result.Err != nil {
+				return result.Err
+			}
+			if len(result.Labels) > 0 {
+				debugf("%s: %d件\n", result.Filename, len(result.Labels))
+				outputs = append(outputs, OutputFile{Path: result.Filename, Rows: len(result.Labels)})
+			}
+			exported = append(exported, result.Labels...)
+		}
+		return nil
+	}
+	if len(heavyChunks) > 0 {
+		if err := collect(ExportCarrierChunks(heavyChunks, heavyConfig, outputPath(outDir, carrierFilenameFormat(filenameFormat, heavyConfig.Carrier)), *parallel, opts, heavyEopts)); err != nil {
+			return err
+		}
+	}
+	if len(specialChunks) > 0 {
+		infof("局留めや私書箱の住所の注文%d件を別のファイルに書き込みます\n", len(special))
+		if err := collect(ExportChunks(specialChunks, outputPath(outDir, specialAddressFilenameFormat(filenameFormat)), *parallel, opts, eopts)); err != nil {
+			return err
+		}
+	}
+	if len(appendOrders) > 0 {
+		labels, r, rows, err := AppendClickpostShippingLabels(*appendFile, appendOrders, opts, eopts)
+		rejects = append(rejects, r...)
+		if err != nil {
+			return err
+		}
+		debugf("%s: %d件を追記\n", *appendFile, len(labels))
+		if len(labels) > 0 {
+			outputs = append(outputs, OutputFile{Path: *appendFile, Rows: rows})
+		}
+		exported = append(exported, labels...)
+	}
+	if *singleFile {
+		filename := outputPath(outDir, singleFilename)
+		labels, r, err := ExportBatchedClickpostShippingLabels(filename, chunks, opts, eopts)
+		rejects = append(rejects, r...)
+		if err != nil {
+			return err
+		}
+		if len(labels) > 0 {
+			debugf("%s: %d件\n", filename, len(labels))
+			outputs = append(outputs, OutputFile{Path: filename, Rows: len(labels)})
+		}
+		exported = labels
+	} else {
+		var results []*ChunkResult
+		if *zipArchive != "" {
+			r, err := ExportChunksZip(outputPath(outDir, *zipArchive), chunks, filenameFormat, opts, eopts)
+			if err != nil {
+				return err
+			}
+			results = r
+		} else if *stream {
+			r, err := ExportChunksStreaming(orders, outputPath(outDir, filenameFormat), carrier.MaxLabels, *maxFiles, opts, eopts)
+			if err != nil {
+				return err
+			}
+			results = r
+		} else if groupChunks != nil {
+			for i, c := range groupChunks {
+				results = append(results, ExportChunks(c, outputPath(outDir, groupFormats[i]), *parallel, opts, eopts)...)
+			}
+		} else {
+			results = ExportChunks(chunks, outputPath(outDir, filenameFormat), *parallel, opts, eopts)
+		}
+		var rows int
+		for _, result := range results {
+			rejects = append(rejects, result.Rejects...)
+			if result.Err != nil {
+				return result.Err
+			}
+			if result.Rows > 0 {
+				debugf("%s: %d件\n", result.Filename, result.Rows)
+				if *zipArchive == "" {
+					outputs = append(outputs, OutputFile{Path: result.Filename, Rows: result.Rows})
+				}
+			}
+			rows += result.Rows
+			if *stream {
+				streamed += result.Rows
+			}
+			exported = append(exported, result.Labels...)
+		}
+		// zipの場合は、一覧にはアップロードするzipファイルを1行で書き込む
+		if *zipArchive != "" && rows > 0 {
+			outputs = append(outputs, OutputFile{Path: outputPath(outDir, *zipArchive), Rows: rows})
+		}
+	}
+	if len(exported)+streamed == 0 {
+		infof("%s\n", localize("注文がありません", "No orders to export"))
+	} else if *manifest != "" {
+		missing, err := WritePackingManifest(outputPath(outDir, *manifest), exported, eopts, orderDates)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			warnf("注意: Created atが空欄か日時として読めないため、梱包リストの注文日を空欄にした注文: %s\n", strings.Join(missing, ", "))
+		}
+		debugf("%s: %d件\n", outputPath(outDir, *manifest), len(exported))
+	}
+	if len(exported) > 0 && *zipList != "" {
+		if err := WriteZipList(outputPath(outDir, *zipList), exported); err != nil {
+			return err
+		}
+		debugf("%s: %d件\n", outputPath(outDir, *zipList), len(UniqueZips(exported)))
+	}
+	if *fixList != "" {
+		if err := WriteFixList(outputPath(outDir, *fixList), rejects, opts, eopts); err != nil {
+			return err
+		}
+		debugf("%s: %d件\n", outputPath(outDir, *fixList), len(rejects))
+	}
+	for _, o := range outputs {
+		auditLog.Log(logLevelInfo, "", "write", fmt.Sprintf("%s: %d件", o.Path, o.Rows))
+	}
+	if len(outputs) > 0 && *checksums != "" {
+		if err := WriteChecksums(outputPath(outDir, *checksums), outputs, eopts); err != nil {
+			return err
+		}
+		debugf("%s: %d件\n", outputPath(outDir, *checksums), len(outputs))
+	}
+	if *stageDir {
+		if len(exported)+streamed == 0 {
+			os.Remove(outDir)
+		} else {
+			infof(localize("確認用のファイルを書き込みました: %s\n", "Staged files for review in: %s\n"), outDir)
+		}
+	}
+	reconciled = true
+	if len(rejects) > 0 {
+		return fmt.Errorf("%w: %d件", ErrOrdersSkipped, len(rejects))
+	}
+	return nil
+}
+
+func runReprocess(filename string, opts ConvertOptions, eopts ExportOptions) error {
+	problems, err := ReprocessClickpostShippingLabels(filename, opts, eopts)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		warnf("%s: %s\n", filename, p)
+	}
+	infof("%sを書き直しました\n", filename)
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %d件", ErrOrdersSkipped, len(problems))
+	}
+	return nil
+}
+
+func runVerify(filename string, c *Carrier) error {
+	problems, err := VerifyClickpostShippingLabels(filename, c)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%sに%d件の問題が見つかりました", filename, len(problems))
+	}
+	fmt.Printf("%sに問題はありません\n", filename)
+	return nil
+}
+
+func runLint(filename string, c *Carrier) error {
+	problems, err := LintClickpostShippingLabels(filename, c)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%sに%d件の違反が見つかりました", filename, len(problems))
+	}
+	fmt.Printf("%sのすべての行が検証ルールを満たしています\n", filename)
+	return nil
 }
 
 // ImportShopifyOrders Shopifyの注文データをCSVとしてインポート
-func ImportShopifyOrders(filename string) ([]*ShopifyOrder, error) {
+// 拡張子が.xlsxの場合はExcelのファイルとして読み込む
+// ファイルが存在しない場合はErrInputNotFound、CSVとして読み込めない場合はErrInputParseを返す
+func ImportShopifyOrders(filename string, enc InputEncoding) ([]*ShopifyOrder, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".xlsx") {
+		return ImportShopifyOrdersXLSX(filename)
+	}
 	inFile, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrInputNotFound, filename)
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer inFile.Close()
-	var orders []*ShopifyOrder
-	if err := gocsv.UnmarshalFile(inFile, &orders); err != nil {
+	aliases, err := LoadHeaderAliases(filename)
+	if err != nil {
+		return nil, err
+	}
+	headers, orders, err := ParseShopifyCSV(inFile, enc, aliases)
+	if err := skipMalformedRows(filename, err); err != nil {
+		if errors.Is(err, gocsv.ErrEmptyCSVFile) {
+			return nil, fmt.Errorf("%w: %sが空です。ヘッダー行もありません", ErrInputParse, filename)
+		}
 		return nil, err
 	}
+	reportColumns(filename, headers)
 	return orders, nil
 }
 
+// 注文データのインポートのエラー。errors.Isで判定できる
+var (
+	ErrInputNotFound = errors.New("ファイルが見つかりません")
+	ErrInputParse    = errors.New("CSVの形式が不正です")
+	ErrInputJSON     = errors.New("JSONの形式が不正です")
+)
+
+// ParseShopifyCSV Shopifyの注文データのCSVを読み込み、ヘッダー行と注文データを返す
+// ヘッダー行を返すので、どの列が注文データに対応付けられたかを呼び出し側で確認できる
+// encの文字コードからUTF-8にしてから読み込む。aliasesがある場合は、ヘッダー行の列名を対応付けに従って変えてから読み込む
+// CSVとして読み込めない場合はErrInputParseを返す
+// -lenient-importの場合は壊れた行を飛ばし、読み込めた注文データと一緒に*MalformedRowsErrorを返す
+func ParseShopifyCSV(r io.Reader, enc InputEncoding, aliases map[string]string) (headers []string, orders []*ShopifyOrder, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b, err = enc.decode(b); err != nil {
+		return nil, nil, err
+	}
+	b = normalizeLineEndings(b)
+	if len(aliases) > 0 {
+		if b, err = renameHeaders(b, aliases); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInputParse, err)
+		}
+	}
+	headers, err = csv.NewReader(bytes.NewReader(b)).Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInputParse, gocsv.ErrEmptyCSVFile)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInputParse, err)
+	}
+	if err := checkSingleColumnHeader(headers); err != nil {
+		return nil, nil, err
+	}
+	if alias := orderNameAlias(headers); alias != nil {
+		if b, err = renameHeaders(b, alias); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInputParse, err)
+		}
+		for i, h := range headers {
+			if to, ok := alias[strings.TrimSpace(h)]; ok {
+				headers[i] = to
+			}
+		}
+	}
+	if lenientImport {
+		orders, rowErrs, err := parseShopifyCSVRows(b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInputParse, err)
+		}
+		if len(rowErrs) > 0 {
+			return headers, orders, &MalformedRowsError{Rows: rowErrs}
+		}
+		return headers, orders, nil
+	}
+	if err := gocsv.UnmarshalBytes(b, &orders); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInputParse, err)
+	}
+	return headers, orders, nil
+}
+
+// ChunkShopifyOrders 送り状の枚数がchunkSizeを超えないように注文データを分割
+// 複数箱の注文は箱数分の枠を消費し、入りきらない場合は次のチャンクに回す
 func ChunkShopifyOrders(items []*ShopifyOrder, chunkSize int) (chunks [][]*ShopifyOrder) {
-	for chunkSize < len(items) {
-		items, chunks = items[chunkSize:], append(chunks, items[0:chunkSize:chunkSize])
+	var chunk []*ShopifyOrder
+	var size int
+	for _, o := range items {
+		n := o.LabelCount()
+		if len(chunk) > 0 && size+n > chunkSize {
+			chunks, chunk, size = append(chunks, chunk), nil, 0
+		}
+		chunk, size = append(chunk, o), size+n
 	}
-	return append(chunks, items)
+	return append(chunks, chunk)
 }
 
 // ExportClickpostShippingLabels Shopifyの注文データをクリックポストの送り状発行用CSVに変換してエクスポート
-func ExportClickpostShippingLabels(filename string, orders []*ShopifyOrder) error {
+// 有効な送り状が1件もない場合はファイルを作成しない。エクスポートした送り状とスキップした注文を返す
+func ExportClickpostShippingLabels(filename string, orders []*ShopifyOrder, opts ConvertOptions, eopts ExportOptions) ([]*ClickpostShippingLabel, []*RejectedOrder, error) {
+	shippingLabels, rejects := BuildClickpostShippingLabels(orders, opts)
+	if len(shippingLabels) == 0 {
+		return nil, rejects, nil
+	}
+	if err := writeLabels(filename, shippingLabels, eopts); err != nil {
+		return nil, rejects, err
+	}
+	return shippingLabels, rejects, nil
+}
+
+// BuildClickpostShippingLabels Shopifyの注文データを送り状に変換し、検証に通った送り状とスキップした注文を返す
+func BuildClickpostShippingLabels(orders []*ShopifyOrder, opts ConvertOptions) ([]*ClickpostShippingLabel, []*RejectedOrder) {
 	var shippingLabels []*ClickpostShippingLabel
+	var rejects []*RejectedOrder
 	for _, o := range orders {
-		label := o.ToClickpostShippingLabel()
-		if err := label.Validate(); err != nil {
-			log.Printf("注文番号:%s エラー:%v\n", o.Name, err)
+		labels, err := convertOrder(o, opts)
+		if err != nil {
+			rejects = append(rejects, &RejectedOrder{Name: o.Name, Err: err, Order: o})
+			if opts.KeepPlaceholders {
+				shippingLabels = append(shippingLabels, placeholderLabels(o)...)
+			}
 			continue
 		}
-		shippingLabels = append(shippingLabels, label)
+		shippingLabels = append(shippingLabels, labels...)
 	}
-	outFile, err := os.Create(filename)
-	if err != nil {
-		return err
+	return shippingLabels, rejects
+}
+
+// convertOrder 1つの注文を送り状に変換して検証する
+// 変換中のパニックはその注文のエラーにして、ほかの注文のエクスポートを止めない
+func convertOrder(o *ShopifyOrder, opts ConvertOptions) (labels []*ClickpostShippingLabel, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			debugf("注文番号:%s のパニック: %v\n%s", o.Name, r, debug.Stack())
+			labels, err = nil, fmt.Errorf("%w: %v", ErrConversionPanic, r)
+		}
+	}()
+	if labels, err = o.ToClickpostShippingLabels(opts); err != nil {
+		return nil, err
 	}
-	defer outFile.Close()
-	gocsv.SetCSVWriter(func(out io.Writer) *gocsv.SafeCSVWriter {
-		writer := csv.NewWriter(transform.NewWriter(out, japanese.ShiftJIS.NewEncoder()))
-		writer.UseCRLF = true
-		return gocsv.NewSafeCSVWriter(writer)
-	})
-	if err := gocsv.MarshalFile(&shippingLabels, outFile); err != nil {
-		return err
+	if opts.NoValidate {
+		if err := validateLabelStructure(labels, opts.carrier()); err != nil {
+			return nil, err
+		}
+		return labels, nil
+	}
+	// 内容品を箱ごとに分けた場合は送り状ごとに内容が異なるので、すべて検証する
+	if err := validateLabels(labels, opts.carrier()); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// placeholderPrefix スキップした注文の代わりの行の氏名に付ける目印
+const placeholderPrefix = "【スキップ】"
+
+// placeholderLabels スキップした注文の代わりに、送り状の位置を保つための行を返す
+// 氏名の欄に目印と注文番号だけを入れ、ほかの欄は空にする。箱数分の枠を使う注文は枠の数だけ返す
+func placeholderLabels(o *ShopifyOrder) []*ClickpostShippingLabel {
+	labels := make([]*ClickpostShippingLabel, 0, o.LabelCount())
+	for i := 0; i < o.LabelCount(); i++ {
+		labels = append(labels, &ClickpostShippingLabel{OrderName: o.Name, ShippingName: placeholderPrefix + o.Name})
+	}
+	return labels
+}
+
+// BuildLabels CLIのデフォルトの変換オプションで注文データを送り状に変換・検証し、送り状とスキップした注文に分ける
+// 分割やファイルの書き込みはしないので、ライブラリとして独自の出力を作る場合に使う
+func BuildLabels(orders []*ShopifyOrder) ([]*ClickpostShippingLabel, []RejectedOrder) {
+	labels, rejects := BuildClickpostShippingLabels(orders, DefaultConvertOptions())
+	values := make([]RejectedOrder, 0, len(rejects))
+	for _, r := range rejects {
+		values = append(values, *r)
+	}
+	return labels, values
+}
+
+// SelectValidOrders 送り状にできる注文とスキップする注文に分ける
+// 変換と検証はBuildClickpostShippingLabelsと同じなので、残した注文はエクスポート時にスキップされない
+func SelectValidOrders(orders []*ShopifyOrder, opts ConvertOptions) ([]*ShopifyOrder, []*RejectedOrder) {
+	var valid []*ShopifyOrder
+	var rejects []*RejectedOrder
+	for _, o := range orders {
+		if _, r := BuildClickpostShippingLabels([]*ShopifyOrder{o}, opts); len(r) > 0 {
+			rejects = append(rejects, r...)
+			continue
+		}
+		valid = append(valid, o)
+	}
+	return valid, rejects
+}
+
+// validateLabels 1つの注文の送り状を配送業者のルールで検証し、最初に見つかったエラーを返す
+func validateLabels(labels []*ClickpostShippingLabel, c *Carrier) error {
+	for _, l := range labels {
+		if err := c.Validate(l); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -80,68 +1283,262 @@ func ExportClickpostShippingLabels(filename string, orders []*ShopifyOrder) erro
 type ShopifyOrder struct {
 	Name             string `csv:"Name"`              // ストア管理画面に表示される注文番号
 	ShippingName     string `csv:"Shipping Name"`     // お客様の氏名
+	ShippingCompany  string `csv:"Shipping Company"`  // 配送先の会社名。この欄は空欄の場合があります
 	ShippingStreet   string `csv:"Shipping Street"`   // 配送先住所として入力されている町名
 	ShippingAddress1 string `csv:"Shipping Address1"` // 150 Elginなど配送先住所の1行目
 	ShippingAddress2 string `csv:"Shipping Address2"` // Suite 800など配送先住所の2行目。この欄は空欄の場合があります
 	ShippingCity     string `csv:"Shipping City"`     // 配送先住所の都市
 	ShippingZip      string `csv:"Shipping Zip"`      // 配送先住所の郵便番号
 	ShippingProvince string `csv:"Shipping Province"` // 配送先の都道府県
+	BoxCount         string `csv:"Box Count"`         // 箱数。空欄の場合は1箱
+	Notes            string `csv:"Notes"`             // 注文メモ。配達の指示が書かれていることがある
+	ShippingPhone    string `csv:"Shipping Phone"`    // 配送先の電話番号。この欄は空欄の場合があります
+	Total            string `csv:"Total"`             // 注文の合計金額
+	LineitemName     string `csv:"Lineitem name"`     // 商品名。商品ごとの行をまとめた注文では改行で区切る
+	Tags             string `csv:"Tags"`              // 注文のタグ。カンマ区切り
+	TotalWeight      string `csv:"Total Weight"`      // 注文の重さ（グラム）
+	PrintOrder       string `csv:"Print Order"`       // 送り状を印刷する順番。この列がある場合は番号順に並べ替える
+	CreatedAt        string `csv:"Created at"`        // 注文日時。例: 2024-01-15 10:23:45 +0900
+	// DefaultContents 読み込んだファイルごとのカンマ区切りの内容品。-in ファイル:内容品 で指定し、空の場合は-contentsの内容品にする
+	DefaultContents string `csv:"-"`
 }
 
-func (s ShopifyOrder) ToClickpostShippingLabel() *ClickpostShippingLabel {
-	return &ClickpostShippingLabel{
-		ShippingZip:       s.ShippingZip,
-		ShippingName:      s.ShippingName,
-		ShippingNameTitle: "様",
-		ShippingAddress1:  s.ShippingProvince + s.ShippingCity,
-		ShippingAddress2:  s.ShippingStreet + s.ShippingAddress1,
-		ShippingAddress3:  s.ShippingAddress2,
-		ShippingContents:  "サプリメント",
+// ConvertOptions 送り状への変換オプション。ゼロ値は日本式の住所としてそのまま変換する
+type ConvertOptions struct {
+	AddressStyle          AddressStyle       // Shopifyの住所欄の書式
+	NamePrefix            string             // お届け先氏名の前に付ける文字列
+	NameSuffix            string             // お届け先氏名の後ろに付ける文字列
+	TruncateName          bool               // 氏名が上限を超える場合に、スキップせずに末尾を切り詰めて目印を付ける
+	NameTruncationMarker  string             // 氏名を切り詰めた目印。空の場合は配送業者の目印
+	Contents              []string           // 内容品の品目。空の場合はデフォルトの内容品
+	SplitBuilding         bool               // 住所1行目に含まれる建物名・部屋番号を別の行に分ける
+	DedupeStreet          bool               // 町名と番地の片方がもう片方に含まれる場合に、重ねずにつなぐ
+	CompanyFallback       bool               // 氏名が空欄で会社名がある場合に、会社名を御中で宛名にする
+	HighValueThreshold    float64            // 合計金額がこの値以上の注文はHighValueContentsを内容品にする。0は無効
+	HighValueContents     string             // 高額注文の内容品
+	ContentsMap           map[string]string  // 商品名から内容品への対応付け。対応付けにない商品名は通常の内容品にする
+	FuzzyContentsMap      bool               // 商品名の対応付けで、全角・半角、空白、大文字・小文字の違いを無視する
+	HonorificRules        []HonorificRule    // 宛名の種類から敬称を決める規則。デフォルトの規則より先に照合する
+	KeepPlaceholders      bool               // スキップした注文の位置に、目印を付けた空の行を残す
+	RequireNameLetters    bool               // 数字や記号だけの氏名をスキップする
+	MaxAddressTotal       int                // 住所1〜4行目の合計の文字数の上限。0は無効
+	DefaultProvince       string             // Shipping Provinceが空欄の場合に使う都道府県
+	CompactAddress        bool               // 住所の空の行を詰めて、上の行から順に入れる
+	AbbreviateAddress     bool               // 住所の行の丁目・番地などを略記して文字数を減らす
+	Abbreviations         map[string]string  // 住所の略記で追加で置き換える文字列の対応付け
+	RejectSpecialAddress  bool               // 局留めや私書箱の住所の注文をスキップする
+	SpecialAddressMarkers []string           // 局留めや私書箱の住所の目印。空の場合はデフォルトの目印
+	ContentsTemplate      *template.Template // 注文ごとの内容品のテンプレート。nilの場合はContentsを使う
+	FieldDefaults         map[string]string  // 送り状の項目が空欄の場合に入れる値。キーはClickpostShippingLabelのフィールド名
+	BarcodeTemplate       string             // バーコードの列の値。{order}を注文番号に置き換える。空の場合は値を作らない
+	SplitCareOf           bool               // 住所に含まれる「山田様方」のような気付の宛名を別の行に分ける
+	NotesLine             bool               // 注文メモを住所3・4行目の空いている行に入れる
+	SplitContents         bool               // 2箱以上の注文で内容品が長すぎる場合に、品目を箱ごとの送り状に分ける
+	ArabicNumerals        bool               // 住所の丁目・番地・番・号の前の漢数字を算用数字にする
+	NormalizeRoom         bool               // Shipping Address2の「Room 301」「Suite 800」のような部屋番号を「301号室」にそろえる
+	StripHonorific        bool               // 氏名の末尾に入力された敬称（様、御中など）を取り除く
+	Honorifics            []string           // 取り除く敬称。空の場合はデフォルトの敬称
+	NormalizeHyphens      bool               // 郵便番号と電話番号の全角の数字とハイフンに似た文字をASCIIに揃える
+	SanitizeControlChars  bool               // 改行やタブなどの制御文字を空白に置き換える。falseの場合は検証エラーになる
+	NoValidate            bool               // 必須の項目の空欄と制御文字のほかは検証せずに送り状にする。緊急時に手で直す前提で使う
+	// Carrier 検証ルールと文字数の上限に使う配送業者の定義。nilの場合はクリックポスト
+	Carrier *Carrier
+	// Hooks 変換の最後に順に呼ばれる関数。ライブラリとして使う場合に、利用者ごとの独自の調整を差し込む
+	Hooks []LabelHook
+	// Trace 変換の過程を記録する関数。fieldは送り状のフィールド名で、項目によらない記録は空になる
+	Trace func(field, detail string)
+}
+
+// carrier 検証ルールと文字数の上限に使う配送業者の定義
+func (o ConvertOptions) carrier() *Carrier {
+	if o.Carrier != nil {
+		return o.Carrier
 	}
+	return Clickpost
 }
 
-type ClickpostShippingLabel struct {
-	ShippingZip       string `csv:"お届け先郵便番号"`  // お届け先郵便番号
-	ShippingName      string `csv:"お届け先氏名"`    // お届け先氏名
-	ShippingNameTitle string `csv:"お届け先敬称"`    // お届け先敬称
-	ShippingAddress1  string `csv:"お届け先住所1行目"` // お届け先住所1行目
-	ShippingAddress2  string `csv:"お届け先住所2行目"` // お届け先住所2行目
-	ShippingAddress3  string `csv:"お届け先住所3行目"` // お届け先住所3行目
-	ShippingAddress4  string `csv:"お届け先住所4行目"` // お届け先住所4行目
-	ShippingContents  string `csv:"内容品"`       // 内容品
+// trace 変換の過程を記録する。Traceが設定されていない場合は何もしない
+func (o ConvertOptions) trace(field, format string, args ...interface{}) {
+	if o.Trace != nil {
+		o.Trace(field, fmt.Sprintf(format, args...))
+	}
 }
 
-// Validate ...
-func (c ClickpostShippingLabel) Validate() error {
-	if c.ShippingZip == "" {
-		return errors.New("お届け先郵便番号は必須です")
+// Validate 変換を始める前に、オプションの組み合わせと値が配送業者の検証ルールで使えるか確かめる
+// どの注文も送り状にできない設定は、すべての注文をスキップする代わりにエラーにする
+func (o ConvertOptions) Validate() error {
+	// 接頭辞と接尾辞だけで氏名の上限に達すると、どの注文も送り状にできない
+	if n := o.carrier().maxLen("ShippingName"); n > 0 && utf8.RuneCountInString(o.NamePrefix+o.NameSuffix) >= n {
+		return fmt.Errorf("氏名の接頭辞と接尾辞は合わせて全角%d文字未満にしてください", n)
 	}
-	if c.ShippingName == "" {
-		return errors.New("お届け先氏名は必須です")
+	if o.HighValueThreshold > 0 {
+		if o.HighValueContents == "" {
+			return errors.New("-high-value-thresholdを指定する場合は-high-value-contentsも指定してください")
+		}
+		if n := o.carrier().maxLen("ShippingContents"); n > 0 && utf8.RuneCountInString(o.HighValueContents) > n {
+			return fmt.Errorf("-high-value-contentsは全角%d文字までです: %s", n, o.HighValueContents)
+		}
 	}
-	if utf8.RuneCountInString(c.ShippingName) > 20 {
-		return errors.New("お届け先氏名は全角20文字までです")
+	if o.FuzzyContentsMap && len(o.ContentsMap) == 0 {
+		return errors.New("-fuzzy-contents-mapを指定する場合は-contents-mapも指定してください")
 	}
-	if c.ShippingAddress1 == "" {
-		return errors.New("お届け先住所1行目は必須です")
+	if len(o.Abbreviations) > 0 && !o.AbbreviateAddress {
+		return errors.New("-abbreviationsを指定する場合は-abbreviate-addressも指定してください")
 	}
-	if utf8.RuneCountInString(c.ShippingAddress1) > 20 {
-		return errors.New("お届け先住所1行目は全角20文字までです")
+	if o.MaxAddressTotal < 0 {
+		return fmt.Errorf("-max-address-totalは0以上を指定してください: %d", o.MaxAddressTotal)
 	}
-	if c.ShippingAddress2 == "" {
-		return errors.New("お届け先住所2行目は必須です")
+	if o.BarcodeTemplate != "" && !strings.Contains(o.BarcodeTemplate, barcodeOrderPlaceholder) {
+		return fmt.Errorf("-barcode-columnには注文番号を入れる%sを含めてください: %s", barcodeOrderPlaceholder, o.BarcodeTemplate)
 	}
-	if utf8.RuneCountInString(c.ShippingAddress2) > 20 {
-		return errors.New("お届け先住所2行目は全角20文字までです")
+	if o.DefaultProvince != "" {
+		if _, ok := LookupPrefecture(o.DefaultProvince); !ok {
+			return fmt.Errorf("-default-provinceに都道府県名を指定してください: %s", o.DefaultProvince)
+		}
 	}
-	if utf8.RuneCountInString(c.ShippingAddress3) > 20 {
-		return errors.New("お届け先住所3行目は全角20文字までです")
+	if err := o.validateTruncateName(); err != nil {
+		return err
 	}
-	if utf8.RuneCountInString(c.ShippingAddress4) > 20 {
-		return errors.New("お届け先住所4行目は全角20文字までです")
+	if err := o.validateFieldDefaults(); err != nil {
+		return err
 	}
-	if utf8.RuneCountInString(c.ShippingContents) > 15 {
-		return errors.New("内容品は全角15文字までです")
+	return validateContents(o.Contents)
+}
+
+// DefaultConvertOptions CLIのフラグのデフォルトと同じ変換オプション
+// ゼロ値とは異なり、会社名の御中、氏名の末尾の敬称の除去、郵便番号と電話番号のハイフンの正規化、住所の空の行を詰める処理が有効になる
+func DefaultConvertOptions() ConvertOptions {
+	return ConvertOptions{CompanyFallback: true, StripHonorific: true, NormalizeHyphens: true, CompactAddress: true}
+}
+
+// barcodeOrderPlaceholder -barcode-columnで注文番号に置き換える文字列
+const barcodeOrderPlaceholder = "{order}"
+
+// barcode バーコードの列の値を作る。スキャンした値と照合しやすいよう注文番号は正規化する
+func (o ConvertOptions) barcode(name string) string {
+	if o.BarcodeTemplate == "" {
+		return ""
 	}
-	return nil
+	return strings.ReplaceAll(o.BarcodeTemplate, barcodeOrderPlaceholder, NormalizeOrderName(name))
+}
+
+// decorateName 氏名に接頭辞と接尾辞を付ける。氏名が空欄の場合は必須エラーになるよう空のままにする
+func (o ConvertOptions) decorateName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return o.NamePrefix + name + o.NameSuffix
+}
+
+func (s ShopifyOrder) ToClickpostShippingLabel(opts ConvertOptions) *ClickpostShippingLabel {
+	if opts.NormalizeHyphens {
+		s.ShippingZip, s.ShippingPhone = normalizeNumberField(s.ShippingZip), normalizeNumberField(s.ShippingPhone)
+	}
+	if s.ShippingProvince == "" && opts.DefaultProvince != "" {
+		s.ShippingProvince, _ = LookupPrefecture(opts.DefaultProvince)
+		opts.trace("", "Shipping Provinceが空欄のため-default-provinceの%sを使う", s.ShippingProvince)
+	}
+	if opts.AddressStyle == AddressStyleEN {
+		s = s.toJapaneseAddressOrder()
+		opts.trace("", "英語式の住所を日本の順に並べ替え（都道府県: %q、Shipping Address1: %q）", s.ShippingProvince, maskedValue(s.ShippingAddress1))
+	}
+	if opts.NormalizeHyphens {
+		opts.trace("ShippingZip", "Shipping Zip（全角の数字とハイフンをASCIIに揃える）")
+	} else {
+		opts.trace("ShippingZip", "Shipping Zip")
+	}
+	name, title := s.ShippingName, "様"
+	nameSource, titleSource := "Shipping Name", "固定値"
+	if name == "" && s.ShippingCompany != "" && opts.CompanyFallback {
+		// 法人宛てで個人名がない場合は会社名を宛名にする
+		name, title = s.ShippingCompany, "御中"
+		nameSource, titleSource = "Shipping Company（Shipping Nameが空欄のため）", "会社宛ての固定値"
+	}
+	if opts.StripHonorific {
+		// 「田中太郎様」のように入力されていると、敬称の列と合わせて「様様」になる
+		if stripped, h := opts.stripHonorific(name); h != "" {
+			name = stripped
+			nameSource += fmt.Sprintf("（末尾の「%s」を除く）", h)
+			if h == "御中" {
+				title, titleSource = "御中", "氏名の末尾の敬称"
+			}
+		}
+	}
+	if titleSource == "固定値" {
+		if t, keyword := opts.honorificFor(name); keyword != "" {
+			title, titleSource = t, fmt.Sprintf("敬称の規則（氏名に「%s」を含む）", keyword)
+		}
+	}
+	if opts.TruncateName {
+		if truncated, ok := opts.truncateName(name); ok {
+			name = truncated
+			nameSource += fmt.Sprintf("（上限を超えるため末尾を切り詰めて「%s」を付ける）", opts.nameTruncationMarker())
+		}
+	}
+	if opts.NamePrefix != "" || opts.NameSuffix != "" {
+		opts.trace("ShippingName", "-name-prefixの値+%s+-name-suffixの値", nameSource)
+	} else {
+		opts.trace("ShippingName", "%s", nameSource)
+	}
+	opts.trace("ShippingNameTitle", "%s", titleSource)
+	lines := ComposeAddressLines(&s, opts)
+	items, highValue := opts.orderContentsItems(s)
+	if highValue {
+		opts.trace("ShippingContents", "Totalが%v以上のため-high-value-contents", opts.HighValueThreshold)
+	} else {
+		opts.trace("ShippingContents", "-contentsの品目を「%s」でつなぐ", contentsSeparator)
+	}
+	label := &ClickpostShippingLabel{
+		OrderName:         s.Name,
+		ShippingZip:       s.ShippingZip,
+		ShippingName:      opts.decorateName(name),
+		ShippingNameTitle: title,
+		ShippingAddress1:  lines[0],
+		ShippingAddress2:  lines[1],
+		ShippingAddress3:  lines[2],
+		ShippingAddress4:  lines[3],
+		ShippingContents:  JoinClickpostContents(items),
+		ContentsItems:     items,
+		Notes:             strings.Join(strings.Fields(s.Notes), " "),
+		ShippingPhone:     opts.carrier().formatPhone(s.ShippingPhone),
+		ShippingWeight:    opts.carrier().formatNumber("ShippingWeight", s.TotalWeight),
+		Barcode:           opts.barcode(s.Name),
+	}
+	if opts.SanitizeControlChars {
+		opts.trace("", "-control-chars sanitizeにより制御文字を空白に置き換える")
+		sanitizeControlChars(label)
+	}
+	opts.applyFieldDefaults(label)
+	for _, hook := range opts.Hooks {
+		hook(&s, label)
+	}
+	return label
+}
+
+// LabelHook 変換した送り状を書き換える関数
+// 住所の並べ替えや敬称・制御文字の処理などの組み込みの変換がすべて終わった後、検証の前に呼ばれる
+// oは変換に使った注文データ（英語式の住所は日本の順に並べ替え済み）で、書き換えても元の注文データには影響しない
+type LabelHook func(o *ShopifyOrder, l *ClickpostShippingLabel)
+
+type ClickpostShippingLabel struct {
+	OrderName         string   `csv:"-"`         // 変換元の注文番号。クリックポストのCSVには出力しない
+	ShippingZip       string   `csv:"お届け先郵便番号"`  // お届け先郵便番号
+	ShippingName      string   `csv:"お届け先氏名"`    // お届け先氏名
+	ShippingNameTitle string   `csv:"お届け先敬称"`    // お届け先敬称
+	ShippingAddress1  string   `csv:"お届け先住所1行目"` // お届け先住所1行目
+	ShippingAddress2  string   `csv:"お届け先住所2行目"` // お届け先住所2行目
+	ShippingAddress3  string   `csv:"お届け先住所3行目"` // お届け先住所3行目
+	ShippingAddress4  string   `csv:"お届け先住所4行目"` // お届け先住所4行目
+	ShippingContents  string   `csv:"内容品"`       // 内容品
+	ContentsItems     []string `csv:"-"`         // 内容品の品目。品名を複数の列に分けて書ける配送業者向け
+	ShippingPiece     string   `csv:"-"`         // 個口番号（1/3など）。クリックポストには個口の列がないため出力しない
+	ShippingPhone     string   `csv:"-"`         // お届け先電話番号。配送業者の書式にそろえる。クリックポストには電話番号の列がないため出力しない
+	ShippingWeight    string   `csv:"-"`         // 注文の重さ（グラム）。数値項目のルールがある配送業者はその書式にそろえる。クリックポストには重さの列がないため出力しない
+	Notes             string   `csv:"-"`         // 注文メモ。配達の指示の列がある配送業者向け。クリックポストには-notes-lineで住所の空いている行に入れる
+	Barcode           string   `csv:"-"`         // 倉庫でバーコードにする値。-barcode-columnの列で出力する
+}
+
+// Validate クリックポストのルールで送り状を検証する
+func (c ClickpostShippingLabel) Validate() error {
+	return Clickpost.Validate(&c)
 }
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// setFlags テストの間だけフラグを設定し、終了時にデフォルトの値に戻す
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		f := flag.Lookup(name)
+		if f == nil {
+			t.Fatalf("フラグ-%sがありません", name)
+		}
+		if err := f.Value.Set(value); err != nil {
+			t.Fatalf("-%s=%s: %v", name, value, err)
+		}
+		t.Cleanup(func() { f.Value.Set(f.DefValue) })
+	}
+}
+
+// captureLog テストの間だけログ（標準エラー出力）を受け取る
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+// captureStdout テストの間だけ標準出力をファイルに受け取り、fを呼んだ後の内容を返す
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() { os.Stdout = stdout }()
+	f()
+	b, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// writeOrdersCSV n件の注文の入力用CSVを書き込む。invalidの注文番号の注文は郵便番号を空欄にする
+func writeOrdersCSV(t *testing.T, filename string, n int, invalid string) {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("Name,Shipping Name,Shipping Street,Shipping Address1,Shipping City,Shipping Zip,Shipping Province\n")
+	for i := 1; i <= n; i++ {
+		name, zip := fmt.Sprintf("#%d", i), "150-0041"
+		if name == invalid {
+			zip = ""
+		}
+		fmt.Fprintf(&b, "%s,山田%d郎,神南1-2-3,,渋谷区,%s,東京都\n", name, i, zip)
+	}
+	if err := os.WriteFile(filename, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunExportsChunks 読み込みから分割、書き込みまでをrunで通して確かめる
+// 送り状にできない1件をスキップした85件の注文が、40件・40件・5件の3つのShift-JISのファイルになる
+func TestRunExportsChunks(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeOrdersCSV(t, "orders.csv", 86, "#11")
+	in = stringsFlag{"orders.csv"}
+	t.Cleanup(func() { in = nil })
+	logs := captureLog(t)
+
+	err := run()
+	if !errors.Is(err, ErrOrdersSkipped) {
+		t.Fatalf("runのエラー = %v、ErrOrdersSkippedを期待", err)
+	}
+	if !strings.Contains(logs.String(), "注文番号:#11 エラー:お届け先郵便番号は必須です") {
+		t.Errorf("スキップした注文がログにありません:\n%s", logs)
+	}
+
+	files, err := filepath.Glob("clickpost-shipping-labels-*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{40, 40, 5}
+	if len(files) != len(want) {
+		t.Fatalf("ファイル = %v、%d件を期待", files, len(want))
+	}
+	const header = "お届け先郵便番号,お届け先氏名,お届け先敬称,お届け先住所1行目,お届け先住所2行目,お届け先住所3行目,お届け先住所4行目,内容品"
+	for i, n := range want {
+		filename := fmt.Sprintf("clickpost-shipping-labels-%d.csv", i)
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if utf8.Valid(b) {
+			t.Errorf("%sがShift-JISではありません", filename)
+		}
+		decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first, _, _ := strings.Cut(string(decoded), "\r\n"); first != header {
+			t.Errorf("%sのヘッダー行 = %q、%qを期待", filename, first, header)
+		}
+		_, labels, err := ReadClickpostShippingLabels(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(labels) != n {
+			t.Errorf("%sの送り状 = %d件、%d件を期待", filename, len(labels), n)
+		}
+		for _, l := range labels {
+			if l.ShippingName == "山田11郎" {
+				t.Errorf("%sにスキップした注文#11の送り状があります", filename)
+			}
+		}
+	}
+}
+
+// TestRunEmptyInput ヘッダー行だけの入力はファイルを書き込まずに正常終了し、ヘッダー行もない入力はエラーにする
+func TestRunEmptyInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{name: "ヘッダー行だけ", content: "Name,Shipping Name,Shipping Zip\n"},
+		{name: "空のファイル", content: "", wantErr: ErrInputParse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("orders.csv", []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			in = stringsFlag{"orders.csv"}
+			t.Cleanup(func() { in = nil })
+			captureLog(t)
+
+			var err error
+			out := captureStdout(t, func() { err = run() })
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("runのエラー = %v、%vを期待", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("runのエラー = %v、nilを期待", err)
+				}
+				if !strings.Contains(out, "注文がありません") {
+					t.Errorf("「注文がありません」が表示されません:\n%s", out)
+				}
+			}
+			files, err := filepath.Glob("clickpost-*.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) > 0 {
+				t.Errorf("ファイルを書き込みました: %v", files)
+			}
+		})
+	}
+}
+
+// TestBuildLabels 送り状と、注文番号と理由の付いたスキップした注文に分ける
+func TestBuildLabels(t *testing.T) {
+	orders := streamTestOrders(4)
+	orders[1].ShippingZip = ""
+	orders[3].BoxCount = "2"
+	labels, rejects := BuildLabels(orders)
+	if len(labels) != 4 {
+		t.Errorf("送り状 = %d枚、#1、#3と2箱の#4で4枚を期待", len(labels))
+	}
+	if len(rejects) != 1 {
+		t.Fatalf("スキップした注文 = %d件、1件を期待", len(rejects))
+	}
+	if rejects[0].Name != "#2" || !errors.Is(rejects[0].Err, ErrZipRequired) {
+		t.Errorf("スキップした注文 = %s %v、#2 %vを期待", rejects[0].Name, rejects[0].Err, ErrZipRequired)
+	}
+}
+
+// TestRunQuotesCommaInAddress カンマを含む住所は、読み込みから書き込みまで1つの項目のまま引用符で囲んで書き込む
+func TestRunQuotesCommaInAddress(t *testing.T) {
+	t.Chdir(t.TempDir())
+	csv := "Name,Shipping Name,Shipping Street,Shipping Address1,Shipping City,Shipping Zip,Shipping Province\n" +
+		"#1,山田太郎,\"神南1-2-3, Xビル\",,渋谷区,150-0041,東京都\n" +
+		"#2,佐藤花子,宇田川町1-1,,渋谷区,150-0042,東京都\n"
+	if err := os.WriteFile("orders.csv", []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in = stringsFlag{"orders.csv"}
+	t.Cleanup(func() { in = nil })
+	captureLog(t)
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile("clickpost-shipping-labels-0.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(decoded), "\"神南1-2-3, Xビル\"") {
+		t.Errorf("カンマを含む住所が引用符で囲まれていません:\n%s", decoded)
+	}
+	_, labels, err := ReadClickpostShippingLabels("clickpost-shipping-labels-0.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 {
+		t.Fatalf("送り状 = %d件、2件を期待", len(labels))
+	}
+	if labels[0].ShippingAddress2 != "神南1-2-3, Xビル" || labels[0].ShippingContents == "" {
+		t.Errorf("1件目の送り状 = %+v、住所2行目「神南1-2-3, Xビル」と内容品を期待", labels[0])
+	}
+	if labels[1].ShippingName != "佐藤花子" {
+		t.Errorf("2件目の氏名 = %q、佐藤花子を期待", labels[1].ShippingName)
+	}
+}
+
+// TestBuildLabelsRecoversPanic 1件の注文の変換がパニックしても、その注文だけをスキップしてほかの注文を変換する
+func TestBuildLabelsRecoversPanic(t *testing.T) {
+	opts := DefaultConvertOptions()
+	opts.Hooks = []LabelHook{func(o *ShopifyOrder, l *ClickpostShippingLabel) {
+		if o.Name == "#3" {
+			var m map[string]int
+			m["panic"]++
+		}
+	}}
+	labels, rejects := BuildClickpostShippingLabels(streamTestOrders(5), opts)
+	if len(labels) != 4 {
+		t.Errorf("送り状 = %d枚、4枚を期待", len(labels))
+	}
+	if len(rejects) != 1 || rejects[0].Name != "#3" || !errors.Is(rejects[0].Err, ErrConversionPanic) {
+		t.Fatalf("スキップした注文 = %v、#3のErrConversionPanicを期待", rejects)
+	}
+	if !strings.Contains(rejects[0].Err.Error(), "nil map") {
+		t.Errorf("スキップした理由 = %v、パニックの内容を期待", rejects[0].Err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+// PackingManifestEntry 梱包リストの1行。送り状と突き合わせるための注文ごとの要約
+type PackingManifestEntry struct {
+	OrderName string `csv:"注文番号"`
+	Recipient string `csv:"お届け先"`
+	Contents  string `csv:"内容品"`
+	BoxCount  int    `csv:"箱数"`
+}
+
+// DatedPackingManifestEntry 注文日の列を加えた梱包リストの1行。送り状のCSVには注文日を出力しないので、経理での突き合わせにはこちらを使う
+type DatedPackingManifestEntry struct {
+	PackingManifestEntry
+	OrderDate string `csv:"注文日"` // ストアのタイムゾーンでの注文日。分からない場合は空欄
+}
+
+// BuildPackingManifest エクスポートした送り状から注文ごとの梱包リストを作る
+// 複数箱の注文は送り状の枚数を箱数として1行にまとめる
+func BuildPackingManifest(labels []*ClickpostShippingLabel) []*PackingManifestEntry {
+	var entries []*PackingManifestEntry
+	byOrder := map[string]*PackingManifestEntry{}
+	for _, l := range labels {
+		if e, ok := byOrder[l.OrderName]; ok {
+			e.BoxCount++
+			continue
+		}
+		e := &PackingManifestEntry{
+			OrderName: l.OrderName,
+			Recipient: l.ShippingName + " " + l.ShippingNameTitle,
+			Contents:  l.ShippingContents,
+			BoxCount:  1,
+		}
+		byOrder[l.OrderName] = e
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// WritePackingManifest 梱包リストを書き込む。配送業者の送り状の列とは関係なく、出力と同じ文字コードで書き込む
+// datesがnilでなければ注文日の列を加え、注文日が分からない注文の注文番号を返す
+func WritePackingManifest(filename string, labels []*ClickpostShippingLabel, eopts ExportOptions, dates map[string]string) ([]string, error) {
+	entries := BuildPackingManifest(labels)
+	if dates == nil {
+		return nil, writeCSV(filename, &entries, eopts)
+	}
+	var missing []string
+	dated := make([]*DatedPackingManifestEntry, 0, len(entries))
+	for _, e := range entries {
+		date, ok := dates[e.OrderName]
+		if !ok {
+			missing = append(missing, e.OrderName)
+		}
+		dated = append(dated, &DatedPackingManifestEntry{PackingManifestEntry: *e, OrderDate: date})
+	}
+	return missing, writeCSV(filename, &dated, eopts)
+}
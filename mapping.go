@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mappingFilename 入力ファイルと同じ場所にある列名の対応付けのファイル名。orders.csvならorders.mapping.json
+func mappingFilename(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".mapping.json"
+}
+
+// LoadHeaderAliases 入力ファイルと同じ場所の.mapping.jsonから、入力の列名からShopifyの列名への対応付けを読み込む
+// {"お名前": "Shipping Name", "郵便番号": "Shipping Zip"} のように書く。ファイルがない場合はnilを返す
+func LoadHeaderAliases(filename string) (map[string]string, error) {
+	b, err := os.ReadFile(mappingFilename(filename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var aliases map[string]string
+	if err := json.Unmarshal(b, &aliases); err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", mappingFilename(filename), err)
+	}
+	debugf("%s: 列名の対応付けを%sから読み込みました\n", filename, mappingFilename(filename))
+	return aliases, nil
+}
+
+// renameHeaders CSVのヘッダー行の列名を対応付けに従って変えたCSVを返す
+func renameHeaders(b []byte, aliases map[string]string) ([]byte, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil || len(records) == 0 {
+		return b, err
+	}
+	for i, h := range records[0] {
+		if to, ok := aliases[strings.TrimSpace(h)]; ok {
+			records[0][i] = to
+		}
+	}
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// orderNameColumns 注文番号の列の列名。Shopifyのエクスポートによって異なるので、先頭から順に探す
+var orderNameColumns = []string{"Name", "Order", "#"}
+
+// orderNameAlias 注文番号の列がName以外の列名の場合に、Nameに読み替える対応付けを返す
+// Nameの列がある場合や、どの列名も見つからない場合はnilを返す
+func orderNameAlias(headers []string) map[string]string {
+	for _, c := range orderNameColumns {
+		for _, h := range headers {
+			if strings.TrimSpace(h) != c {
+				continue
+			}
+			if c == "Name" {
+				return nil
+			}
+			return map[string]string{c: "Name"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestImportOrderNameAliases 注文番号の列がName、Order、#のどれでも注文番号を読み込み、どれもない場合は警告する
+func TestImportOrderNameAliases(t *testing.T) {
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{column: "Name", want: "#1001"},
+		{column: "Order", want: "#1001"},
+		{column: "#", want: "#1001"},
+		{column: "Order Number", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "orders.csv")
+			csv := tt.column + ",Shipping Name,Shipping Zip\n#1001,山田太郎,150-0041\n"
+			if err := os.WriteFile(filename, []byte(csv), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			logs := captureLog(t)
+			orders, err := ImportShopifyOrders(filename, InputEncodingUTF8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != 1 || orders[0].Name != tt.want {
+				t.Fatalf("注文番号 = %v、%qを期待", orders, tt.want)
+			}
+			if warned := strings.Contains(logs.String(), "注文番号の列"); warned != (tt.want == "") {
+				t.Errorf("注文番号の列がない警告 = %v、%vを期待:\n%s", warned, tt.want == "", logs)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maskRunes 先頭のkeep文字を残し、残りを1文字ずつ「*」に置き換える
+// バイト単位ではなく文字単位で置き換えるので、全角文字が途中で切れない
+func maskRunes(s string, keep int) string {
+	runes := []rune(s)
+	if len(runes) <= keep {
+		return s
+	}
+	return string(runes[:keep]) + strings.Repeat("*", len(runes)-keep)
+}
+
+// MaskName 氏名の一部を伏せる。「田中太郎」は「田中**」になる
+func MaskName(s string) string {
+	keep := 2
+	if len([]rune(s)) <= 2 {
+		keep = 1
+	}
+	return maskRunes(s, keep)
+}
+
+// MaskZip 郵便番号の上3桁以外を伏せる。「150-0041」は「150-****」になる
+func MaskZip(s string) string {
+	return maskDigits(s, 3)
+}
+
+// MaskPhone 電話番号の先頭3桁以外を伏せる。「090-1234-5678」は「090-****-****」になる
+func MaskPhone(s string) string {
+	return maskDigits(s, 3)
+}
+
+// maskDigits 先頭のkeep桁の数字以外の数字を「*」に置き換える。ハイフンなどの区切りは残す
+func maskDigits(s string, keep int) string {
+	var b strings.Builder
+	digits := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digits++
+			if digits > keep {
+				r = '*'
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// MaskClickpostShippingLabel 氏名・郵便番号・住所・電話番号の一部と注文メモを伏せた送り状のコピーを返す
+// 都道府県と市区町村の住所1行目は残し、町名以降を伏せる。注文メモは名前や住所を含むことがあるのですべて伏せる
+func MaskClickpostShippingLabel(l *ClickpostShippingLabel) *ClickpostShippingLabel {
+	masked := *l
+	masked.ShippingZip = MaskZip(l.ShippingZip)
+	masked.ShippingName = MaskName(l.ShippingName)
+	masked.ShippingAddress2 = maskRunes(l.ShippingAddress2, 2)
+	masked.ShippingAddress3 = maskRunes(l.ShippingAddress3, 0)
+	masked.ShippingAddress4 = maskRunes(l.ShippingAddress4, 0)
+	masked.ShippingPhone = MaskPhone(l.ShippingPhone)
+	masked.Notes = maskRunes(l.Notes, 0)
+	return &masked
+}
+
+// MaskShopifyOrder 氏名・郵便番号・住所・電話番号の一部と注文メモを伏せた注文データのコピーを返す
+// 送り状と同じく、都道府県と市区町村は残す
+func MaskShopifyOrder(o *ShopifyOrder) *ShopifyOrder {
+	masked := *o
+	masked.ShippingName = MaskName(o.ShippingName)
+	masked.ShippingZip = MaskZip(o.ShippingZip)
+	masked.ShippingStreet = maskRunes(o.ShippingStreet, 2)
+	masked.ShippingAddress1 = maskRunes(o.ShippingAddress1, 0)
+	masked.ShippingAddress2 = maskRunes(o.ShippingAddress2, 0)
+	masked.ShippingPhone = MaskPhone(o.ShippingPhone)
+	masked.Notes = maskRunes(o.Notes, 0)
+	return &masked
+}
+
+// maskedValue トレースなどに添える入力の値。-maskの場合は先頭の2文字以外を伏せる
+func maskedValue(s string) string {
+	if maskOutput {
+		return maskRunes(s, 2)
+	}
+	return s
+}
+
+// MaskPreviewLabels プレビューの送り状の個人情報を伏せる
+func MaskPreviewLabels(previews []*PreviewLabel) {
+	for _, p := range previews {
+		p.Label = MaskClickpostShippingLabel(p.Label)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMaskClickpostShippingLabel 個人情報の項目を伏せ、元の送り状は変えない
+func TestMaskClickpostShippingLabel(t *testing.T) {
+	l := &ClickpostShippingLabel{
+		ShippingZip:      "150-0041",
+		ShippingName:     "山田太郎",
+		ShippingAddress1: "東京都渋谷区",
+		ShippingAddress2: "神南1-2-3",
+		ShippingAddress3: "渋谷マンション",
+		ShippingAddress4: "301号室",
+		ShippingPhone:    "090-1234-5678",
+		Notes:            "山田様方 不在時は宅配ボックス",
+	}
+	original := *l
+	masked := MaskClickpostShippingLabel(l)
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"ShippingZip", masked.ShippingZip, "150-****"},
+		{"ShippingName", masked.ShippingName, "山田**"},
+		{"ShippingAddress1", masked.ShippingAddress1, "東京都渋谷区"},
+		{"ShippingAddress2", masked.ShippingAddress2, "神南*****"},
+		{"ShippingAddress3", masked.ShippingAddress3, "*******"},
+		{"ShippingAddress4", masked.ShippingAddress4, "*****"},
+		{"ShippingPhone", masked.ShippingPhone, "090-****-****"},
+		{"Notes", masked.Notes, "***************"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q、%qを期待", tt.field, tt.got, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(*l, original) {
+		t.Errorf("元の送り状が変わりました: %+v", l)
+	}
+}
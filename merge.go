@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+)
+
+// MergeShopifyOrders 同じ注文番号の行を1つの注文にまとめる
+// Shopifyのエクスポートは商品ごとに1行になり、配送先は1行目にしか入らないことがある。
+// また50件の上限で1つの注文が2つのファイルに分かれることもあるため、
+// 最初に現れた行の位置に、空欄の項目を後の行で埋めた注文を残す
+func MergeShopifyOrders(orders []*ShopifyOrder) []*ShopifyOrder {
+	var merged []*ShopifyOrder
+	byName := map[string]*ShopifyOrder{}
+	for _, o := range orders {
+		name := NormalizeOrderName(o.Name)
+		if first, ok := byName[name]; ok && name != "" {
+			// 商品名は商品ごとの行に1つずつ入るので、埋めずにすべて残す
+			if first.LineitemName != "" && o.LineitemName != "" {
+				first.LineitemName += lineitemSeparator + o.LineitemName
+			}
+			fillBlankFields(first, o)
+			continue
+		}
+		c := *o
+		byName[name] = &c
+		merged = append(merged, &c)
+	}
+	return merged
+}
+
+// fillBlankFields dstの空欄の文字列項目をsrcの値で埋める
+func fillBlankFields(dst, src *ShopifyOrder) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	for i := 0; i < d.NumField(); i++ {
+		if f := d.Field(i); f.Kind() == reflect.String && f.String() == "" {
+			f.SetString(s.Field(i).String())
+		}
+	}
+}
+
+// FindDuplicateOrders 配送先が入った行がまったく同じ内容で複数回現れる注文の注文番号を返す
+// 商品ごとの2行目以降は配送先が空欄になるので、配送先まで同じ行は同じエクスポートを重ねて読み込んだ可能性が高い
+// MergeShopifyOrdersでまとめる前の注文データを渡す
+func FindDuplicateOrders(orders []*ShopifyOrder) []string {
+	var names []string
+	seen := map[ShopifyOrder]bool{}
+	reported := map[string]bool{}
+	for _, o := range orders {
+		name := NormalizeOrderName(o.Name)
+		if name == "" || o.ShippingName+o.ShippingAddress1+o.ShippingZip == "" {
+			continue
+		}
+		row := *o
+		row.Name = name
+		// 同じエクスポートを別の内容品で重ねて指定した場合も重複とみなす
+		row.DefaultContents = ""
+		if seen[row] && !reported[name] {
+			reported[name] = true
+			names = append(names, o.Name)
+		}
+		seen[row] = true
+	}
+	return names
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMergeOrderSplitAcrossFiles 50件の上限で2つのファイルに分かれた注文を、注文番号で1つにまとめる
+func TestMergeOrderSplitAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// #1001の1行目は1つ目のファイルの最後、#1002は配送先が2つ目のファイルの行にだけある
+		"orders-1.csv": "Name,Shipping Name,Shipping Street,Shipping City,Shipping Zip,Shipping Province,Lineitem name\n" +
+			"#1000,佐藤花子,神南1-1-1,渋谷区,150-0041,東京都,ビタミンC\n" +
+			"#1001,山田太郎,神南1-2-3,渋谷区,150-0041,東京都,ビタミンC\n" +
+			"#1002,,,,,,亜鉛\n",
+		"orders-2.csv": "Name,Shipping Name,Shipping Street,Shipping City,Shipping Zip,Shipping Province,Lineitem name\n" +
+			"#1001,,,,,,鉄\n" +
+			"#1002,鈴木一郎,宇田川町1-1,渋谷区,150-0042,東京都,マグネシウム\n",
+	}
+	var orders []*ShopifyOrder
+	for _, name := range []string{"orders-1.csv", "orders-2.csv"} {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		o, err := ImportShopifyOrders(filename, InputEncodingUTF8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orders = append(orders, o...)
+	}
+	captureLog(t)
+
+	merged := MergeShopifyOrders(orders)
+	if len(merged) != 3 {
+		t.Fatalf("まとめた注文 = %d件、3件を期待", len(merged))
+	}
+	tests := []struct {
+		name, shippingName, zip, lineitems string
+	}{
+		{"#1000", "佐藤花子", "150-0041", "ビタミンC"},
+		{"#1001", "山田太郎", "150-0041", "ビタミンC" + lineitemSeparator + "鉄"},
+		{"#1002", "鈴木一郎", "150-0042", "亜鉛" + lineitemSeparator + "マグネシウム"},
+	}
+	for i, tt := range tests {
+		o := merged[i]
+		if o.Name != tt.name || o.ShippingName != tt.shippingName || o.ShippingZip != tt.zip || o.LineitemName != tt.lineitems {
+			t.Errorf("%d番目の注文 = %s %s %s %q、%s %s %s %qを期待", i, o.Name, o.ShippingName, o.ShippingZip, o.LineitemName, tt.name, tt.shippingName, tt.zip, tt.lineitems)
+		}
+	}
+}
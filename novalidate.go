@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// validateLabelStructure -no-validateでも省かない最低限の確認。必須の項目の空欄と、改行やタブなどの制御文字だけを確かめる
+// 必須の欄が空の行や改行を含む行があると、配送業者がファイル全体を読めなくなることがある
+func validateLabelStructure(labels []*ClickpostShippingLabel, c *Carrier) error {
+	for _, l := range labels {
+		v := reflect.ValueOf(l).Elem()
+		for _, r := range c.Fields {
+			value := v.FieldByName(r.Field).String()
+			if r.Required && value == "" {
+				return r.requiredError()
+			}
+			if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+				return r.controlCharError()
+			}
+		}
+	}
+	return nil
+}
+
+// CountUnvalidatedLabels -no-validateで書き込む送り状のうち、検証していれば通らなかった枚数を数える
+// optsはNoValidateを有効にした変換オプションを渡す
+func CountUnvalidatedLabels(orders []*ShopifyOrder, opts ConvertOptions) int {
+	labels, _ := BuildClickpostShippingLabels(orders, opts)
+	n := 0
+	for _, l := range labels {
+		if opts.carrier().Validate(l) != nil {
+			n++
+		}
+	}
+	return n
+}
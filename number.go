@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// NumberFormat 送り状の数値項目の書式
+// 配送業者によって「1000」「1,000」「1000g」のように求める書式が異なるため、項目ごとに宣言する
+type NumberFormat struct {
+	Grouping bool   // 3桁ごとにカンマで区切る
+	Unit     string // 数値の後ろに付ける単位。例: g、円
+}
+
+// ErrInvalidNumber 数値項目に数値として読めない値が入っている
+var ErrInvalidNumber = &ValidationError{Code: "invalid_number", Message: "数値で指定してください"}
+
+// ParseNumber 数値項目の元の値を整数として読む
+// 全角数字、桁区切りのカンマ、前後の空白、末尾の単位は許容する
+func (f NumberFormat) ParseNumber(s string) (int64, error) {
+	v := strings.TrimSpace(width.Fold.String(s))
+	if f.Unit != "" {
+		v = strings.TrimSpace(strings.TrimSuffix(v, width.Fold.String(f.Unit)))
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(v, ",", ""), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidNumber, s)
+	}
+	return n, nil
+}
+
+// Format 整数を書式に従って文字列にする
+func (f NumberFormat) Format(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if f.Grouping {
+		s = groupDigits(s)
+	}
+	return s + f.Unit
+}
+
+// FormatString 元の値を数値として読んでから書式に従って文字列にする。空欄は空欄のまま返す
+func (f NumberFormat) FormatString(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	n, err := f.ParseNumber(s)
+	if err != nil {
+		return "", err
+	}
+	return f.Format(n), nil
+}
+
+// groupDigits 10進数の文字列を3桁ごとにカンマで区切る
+func groupDigits(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String()
+}
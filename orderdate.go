@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// shopifyCreatedAtLayout ShopifyのエクスポートのCreated atの書式。例: 2024-01-15 10:23:45 +0900
+const shopifyCreatedAtLayout = "2006-01-02 15:04:05 -0700"
+
+// orderDateLayout 梱包リストに書き込む注文日の書式
+const orderDateLayout = "2006-01-02"
+
+// ParseOrderCreatedAt 注文データのCreated atを読む。ShopifyのCSVの書式のほか、JSONの注文データ向けにRFC 3339も受け付ける
+func ParseOrderCreatedAt(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{shopifyCreatedAtLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Created atを日時として読めません: %q", s)
+}
+
+// LoadStoreLocation ストアのタイムゾーンを読む。例: Asia/Tokyo
+// タイムゾーンのデータがない環境でもデフォルトのAsia/Tokyoは使えるよう、読めない場合は+09:00にする
+func LoadStoreLocation(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if name == "Asia/Tokyo" {
+			return time.FixedZone("JST", 9*60*60), nil
+		}
+		return nil, fmt.Errorf("-timezoneのタイムゾーンを読めません: %s", name)
+	}
+	return loc, nil
+}
+
+// OrderDates 注文番号から、ストアのタイムゾーンでの注文日への対応付けを作る
+// 深夜の注文が別の日付にならないよう、Created atの時差によらずストアのタイムゾーンの日付にする
+// Created atが空欄か読めない注文は含めない
+func OrderDates(orders []*ShopifyOrder, loc *time.Location) map[string]string {
+	dates := map[string]string{}
+	for _, o := range orders {
+		t, err := ParseOrderCreatedAt(o.CreatedAt)
+		if err != nil {
+			continue
+		}
+		dates[o.Name] = t.In(loc).Format(orderDateLayout)
+	}
+	return dates
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// コンソールへの出力レベル。-quietと-verboseで切り替える
+var (
+	quietOutput   bool // エラー以外を出力しない
+	verboseOutput bool // 詳細な情報も出力する
+	maskOutput    bool // ログやエラーに添える値の個人情報を伏せる。-maskで設定する
+)
+
+// infof 件数などの情報を標準出力に出力する
+func infof(format string, args ...interface{}) {
+	if !quietOutput {
+		fmt.Printf(format, args...)
+	}
+}
+
+// warnf スキップや注意をログ（標準エラー出力）に出力する
+func warnf(format string, args ...interface{}) {
+	if !quietOutput {
+		log.Printf(format, args...)
+	}
+}
+
+// debugf -verboseのときだけ詳細な情報を標準出力に出力する
+func debugf(format string, args ...interface{}) {
+	if verboseOutput && !quietOutput {
+		fmt.Printf(format, args...)
+	}
+}
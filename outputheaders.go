@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// LoadColumnHeaders 送り状のCSVのヘッダーの列名を、ClickpostShippingLabelのフィールド名からの対応付けのJSONファイルで読み込む
+// {"ShippingName": "お届け先名"} のように書き、書かなかったフィールドは今の列名のままにする。-rename-columnsと同じ、今の列名から新しい列名への対応付けにして返す
+// クリックポストのテンプレートの改訂で列名が変わった場合に、再ビルドせずに合わせるために使う。入力の.mapping.jsonの出力側にあたる
+func LoadColumnHeaders(filename string) (map[string]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal(b, &overrides); err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", filename, err)
+	}
+	renames := map[string]string{}
+	fields := map[string]string{} // 置き換えた後の列名から、その列のフィールド名
+	labelType := reflect.TypeOf(ClickpostShippingLabel{})
+	for i := 0; i < labelType.NumField(); i++ {
+		f := labelType.Field(i)
+		header := f.Tag.Get("csv")
+		if header == "" || header == "-" {
+			continue
+		}
+		if h, ok := overrides[f.Name]; ok {
+			if h = strings.TrimSpace(h); h == "" {
+				return nil, fmt.Errorf("%sの%sの列名が空です", filename, f.Name)
+			}
+			renames[header], header = h, h
+		}
+		// 置き換えた後にすべての列に異なる列名があるか確かめる
+		if prev, ok := fields[header]; ok {
+			return nil, fmt.Errorf("%sで%sと%sの列名が同じ「%s」になります", filename, prev, f.Name, header)
+		}
+		fields[header] = f.Name
+	}
+	var unknown []string
+	for field := range overrides {
+		if f, ok := labelType.FieldByName(field); !ok || f.Tag.Get("csv") == "-" {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%sの「%s」はクリックポストの送り状の列のフィールドにありません", filename, strings.Join(unknown, "」「"))
+	}
+	return renames, nil
+}
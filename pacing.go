@@ -0,0 +1,32 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// writePacer チャンクのファイルの書き込みの間隔を空ける。ネットワークの共有フォルダに続けて書き込むと詰まることがあるため
+// 間隔は前のファイルを書き終えてから次のファイルを書き始めるまでで、1つのファイルの書き込みの途中では待たない
+type writePacer struct {
+	mu   sync.Mutex
+	last time.Time // 前のファイルを書き終えた時刻。まだ書き込んでいない場合はゼロ値
+}
+
+// chunkWritePacer チャンクのファイルの書き込みで共有する間隔の管理。重い注文や局留めのファイルも合わせて間隔を空ける
+var chunkWritePacer writePacer
+
+// pace 前のファイルを書き終えてからdelayが経つまで待ってwriteを呼ぶ。delayが0以下の場合は待たない
+func (p *writePacer) pace(delay time.Duration, write func() error) error {
+	if delay <= 0 {
+		return write()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.last.IsZero() {
+		if wait := delay - time.Since(p.last); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	defer func() { p.last = time.Now() }()
+	return write()
+}
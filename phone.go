@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// PhoneFormat 電話番号の書式
+type PhoneFormat string
+
+const (
+	// PhoneFormatHyphen 090-1234-5678
+	PhoneFormatHyphen PhoneFormat = "hyphen"
+	// PhoneFormatPlain 09012345678
+	PhoneFormatPlain PhoneFormat = "plain"
+)
+
+// ErrInvalidPhone 電話番号が日本の電話番号として読めない
+var ErrInvalidPhone = &ValidationError{Code: "phone_invalid", Message: "電話番号は市外局番から10桁か11桁で指定してください"}
+
+// phoneDigits 電話番号から数字だけを取り出し、+81の国際番号を国内の0から始まる番号にする
+func phoneDigits(s string) string {
+	s = strings.TrimSpace(width.Fold.String(s))
+	international := strings.HasPrefix(s, "+81")
+	if international {
+		s = strings.TrimPrefix(s, "+81")
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	// +81 (0)90-... のように国内の0を残した書き方もある
+	if international && !strings.HasPrefix(digits, "0") {
+		digits = "0" + digits
+	}
+	return digits
+}
+
+// NormalizePhone いろいろな書き方の電話番号を国内の書式にそろえる
+// 「090-1234-5678」「09012345678」「+81 90-1234-5678」はどれも同じ番号になる。読めない場合はErrInvalidPhoneを返す
+func NormalizePhone(s string, f PhoneFormat) (string, error) {
+	d := phoneDigits(s)
+	if !strings.HasPrefix(d, "0") || len(d) < 10 || len(d) > 11 {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPhone, s)
+	}
+	if f == PhoneFormatPlain {
+		return d, nil
+	}
+	return hyphenatePhone(d), nil
+}
+
+// hyphenatePhone 数字だけの電話番号をハイフンで区切る
+// 携帯電話・IP電話・フリーダイヤルと東京・大阪以外の固定電話は、市外局番の桁数が地域で異なるため3桁に揃えて区切る
+func hyphenatePhone(d string) string {
+	switch {
+	case len(d) == 11:
+		return d[:3] + "-" + d[3:7] + "-" + d[7:]
+	case strings.HasPrefix(d, "0120") || strings.HasPrefix(d, "0800"):
+		return d[:4] + "-" + d[4:7] + "-" + d[7:]
+	case strings.HasPrefix(d, "03") || strings.HasPrefix(d, "06"):
+		return d[:2] + "-" + d[2:6] + "-" + d[6:]
+	}
+	return d[:3] + "-" + d[3:6] + "-" + d[6:]
+}
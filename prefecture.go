@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+)
+
+// prefectures 都道府県の漢字表記とローマ字表記の対応
+var prefectures = []struct {
+	Name   string // 漢字表記
+	Romaji string // ローマ字表記（小文字、接尾辞なし）
+}{
+	{"北海道", "hokkaido"},
+	{"青森県", "aomori"},
+	{"岩手県", "iwate"},
+	{"宮城県", "miyagi"},
+	{"秋田県", "akita"},
+	{"山形県", "yamagata"},
+	{"福島県", "fukushima"},
+	{"茨城県", "ibaraki"},
+	{"栃木県", "tochigi"},
+	{"群馬県", "gunma"},
+	{"埼玉県", "saitama"},
+	{"千葉県", "chiba"},
+	{"東京都", "tokyo"},
+	{"神奈川県", "kanagawa"},
+	{"新潟県", "niigata"},
+	{"富山県", "toyama"},
+	{"石川県", "ishikawa"},
+	{"福井県", "fukui"},
+	{"山梨県", "yamanashi"},
+	{"長野県", "nagano"},
+	{"岐阜県", "gifu"},
+	{"静岡県", "shizuoka"},
+	{"愛知県", "aichi"},
+	{"三重県", "mie"},
+	{"滋賀県", "shiga"},
+	{"京都府", "kyoto"},
+	{"大阪府", "osaka"},
+	{"兵庫県", "hyogo"},
+	{"奈良県", "nara"},
+	{"和歌山県", "wakayama"},
+	{"鳥取県", "tottori"},
+	{"島根県", "shimane"},
+	{"岡山県", "okayama"},
+	{"広島県", "hiroshima"},
+	{"山口県", "yamaguchi"},
+	{"徳島県", "tokushima"},
+	{"香川県", "kagawa"},
+	{"愛媛県", "ehime"},
+	{"高知県", "kochi"},
+	{"福岡県", "fukuoka"},
+	{"佐賀県", "saga"},
+	{"長崎県", "nagasaki"},
+	{"熊本県", "kumamoto"},
+	{"大分県", "oita"},
+	{"宮崎県", "miyazaki"},
+	{"鹿児島県", "kagoshima"},
+	{"沖縄県", "okinawa"},
+}
+
+// romajiPrefectureSuffixes ローマ字表記の都道府県名に付くことがある接尾辞
+var romajiPrefectureSuffixes = []string{" prefecture", "-ken", " ken", "-to", " to", "-fu", " fu", "-do", " do"}
+
+// LookupPrefecture 漢字またはローマ字の都道府県名から漢字表記を返す
+func LookupPrefecture(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	for _, p := range prefectures {
+		if s == p.Name {
+			return p.Name, true
+		}
+	}
+	key := strings.ToLower(s)
+	for _, suffix := range romajiPrefectureSuffixes {
+		if trimmed := strings.TrimSuffix(key, suffix); trimmed != key {
+			key = trimmed
+			break
+		}
+	}
+	// Tōkyō のような長音記号付きの表記にも対応する
+	key = strings.NewReplacer("ō", "o", "ū", "u", "ou", "o", "oo", "o").Replace(key)
+	for _, p := range prefectures {
+		if key == p.Romaji {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// PreviewLabel プレビュー用の送り状と検証結果
+type PreviewLabel struct {
+	OrderName string                  // 注文番号
+	Label     *ClickpostShippingLabel // 変換後の送り状
+	Valid     bool                    // 検証に通ったかどうか
+	Error     string                  // 検証エラー。有効な場合は空
+}
+
+// BuildPreviewLabels Shopifyの注文データを送り状に変換して検証結果と合わせて返す
+// 複数箱の注文は送り状1枚ごとに1件になる
+func BuildPreviewLabels(orders []*ShopifyOrder, opts ConvertOptions) []*PreviewLabel {
+	previews := make([]*PreviewLabel, 0, len(orders))
+	for _, o := range orders {
+		labels, err := o.ToClickpostShippingLabels(opts)
+		if err != nil {
+			previews = append(previews, &PreviewLabel{
+				OrderName: o.Name,
+				Label:     o.ToClickpostShippingLabel(opts),
+				Error:     err.Error(),
+			})
+			continue
+		}
+		for _, label := range labels {
+			preview := &PreviewLabel{
+				OrderName: o.Name,
+				Label:     label,
+				Valid:     true,
+			}
+			if err := opts.carrier().Validate(label); err != nil {
+				preview.Valid = false
+				preview.Error = err.Error()
+			}
+			previews = append(previews, preview)
+		}
+	}
+	return previews
+}
+
+// MarshalPreviewJSON 送り状のプレビューをJSONに変換
+// フィールド名はcsvタグではなく構造体のフィールド名を使う
+func MarshalPreviewJSON(previews []*PreviewLabel) ([]byte, error) {
+	return json.MarshalIndent(previews, "", "  ")
+}
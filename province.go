@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// unknownProvince 都道府県が分からない送り状をまとめる見出し
+const unknownProvince = "不明"
+
+// ProvinceCount 都道府県ごとの送り状の枚数
+type ProvinceCount struct {
+	Province string // 都道府県の漢字表記。分からない場合は「不明」
+	Labels   int    // 送り状の枚数
+}
+
+// CountLabelsByProvince 送り状を都道府県ごとに数え、枚数の多い順に返す
+// 都道府県は郵便番号の補完や英語式の住所の並べ替えを終えた住所1行目の先頭から読むので、入力の表記の揺れによらない
+// 同じ枚数の都道府県は北から順に並べ、「不明」は最後にする。スキップした注文の代わりの行は数えない
+func CountLabelsByProvince(labels []*ClickpostShippingLabel) []ProvinceCount {
+	counts := map[string]int{}
+	for _, l := range labels {
+		if strings.HasPrefix(l.ShippingName, placeholderPrefix) {
+			continue
+		}
+		counts[labelPrefecture(l)]++
+	}
+	order := map[string]int{unknownProvince: len(prefectures)}
+	for i, p := range prefectures {
+		order[p.Name] = i
+	}
+	result := make([]ProvinceCount, 0, len(counts))
+	for province, n := range counts {
+		result = append(result, ProvinceCount{Province: province, Labels: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Labels != result[j].Labels {
+			return result[i].Labels > result[j].Labels
+		}
+		return order[result[i].Province] < order[result[j].Province]
+	})
+	return result
+}
+
+// labelPrefecture 送り状の住所1行目の先頭の都道府県を返す。都道府県で始まらない場合は「不明」
+func labelPrefecture(l *ClickpostShippingLabel) string {
+	for _, p := range prefectures {
+		if strings.HasPrefix(l.ShippingAddress1, p.Name) {
+			return p.Name
+		}
+	}
+	return unknownProvince
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// InputEncoding 読み込むShopifyの注文データの文字コード
+type InputEncoding string
+
+const (
+	// InputEncodingUTF8 Shopifyから書き出したままのCSV
+	InputEncodingUTF8 InputEncoding = "utf8"
+	// InputEncodingShiftJIS Excelで保存し直したCSV（CP932）
+	InputEncodingShiftJIS InputEncoding = "sjis"
+	// InputEncodingAuto UTF-8として正しくない場合はShift-JISとみなす
+	InputEncodingAuto InputEncoding = "auto"
+)
+
+// ParseInputEncoding 文字列から読み込む文字コードを返す
+func ParseInputEncoding(s string) (InputEncoding, error) {
+	switch enc := InputEncoding(s); enc {
+	case InputEncodingUTF8, InputEncodingShiftJIS, InputEncodingAuto:
+		return enc, nil
+	}
+	return "", fmt.Errorf("読み込む文字コードはutf8、sjis、autoのいずれかを指定してください: %s", s)
+}
+
+// decode 読み込んだバイト列をUTF-8にする。空の場合はUTF-8とみなす
+func (enc InputEncoding) decode(b []byte) ([]byte, error) {
+	if enc == InputEncodingAuto {
+		enc = InputEncodingUTF8
+		if !utf8.Valid(b) {
+			enc = InputEncodingShiftJIS
+		}
+		debugf("読み込む文字コード: %s\n", enc)
+	}
+	if enc != InputEncodingShiftJIS {
+		return b, nil
+	}
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Shift-JISとして読み込めません: %w", ErrInputParse, err)
+	}
+	return decoded, nil
+}
+
+// normalizeLineEndings 改行コードのCRLFとCRだけの改行をLFにそろえる
+// 古いMacの形式に変換されたCSVはCRだけで行が区切られ、そのままでは1行として読み込まれてしまう
+func normalizeLineEndings(b []byte) []byte {
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(b, []byte("\r"), []byte("\n"))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestImportShiftJISInput Excelで保存し直したCP932のファイルを、sjisか自動判定で読み込む
+func TestImportShiftJISInput(t *testing.T) {
+	captureLog(t)
+	utf8File := filepath.Join(t.TempDir(), "orders.csv")
+	writeOrdersCSV(t, utf8File, 1, "")
+	tests := []struct {
+		name     string
+		filename string
+		enc      InputEncoding
+		want     []string
+	}{
+		{name: "sjis", filename: "testdata/orders-sjis.csv", enc: InputEncodingShiftJIS, want: []string{"山田太郎", "髙橋花子"}},
+		{name: "自動判定でShift-JIS", filename: "testdata/orders-sjis.csv", enc: InputEncodingAuto, want: []string{"山田太郎", "髙橋花子"}},
+		{name: "自動判定でUTF-8", filename: utf8File, enc: InputEncodingAuto, want: []string{"山田1郎"}},
+		{name: "UTF-8", filename: utf8File, enc: InputEncodingUTF8, want: []string{"山田1郎"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders, err := ImportShopifyOrders(tt.filename, tt.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != len(tt.want) {
+				t.Fatalf("注文 = %d件、%d件を期待", len(orders), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if orders[i].ShippingName != want {
+					t.Errorf("%d件目のShipping Name = %q、%qを期待", i+1, orders[i].ShippingName, want)
+				}
+			}
+			if tt.filename == "testdata/orders-sjis.csv" && orders[1].ShippingStreet != "宇田川町1-1 ①号室" {
+				t.Errorf("Shipping Street = %q、CP932の機種依存文字を含む「宇田川町1-1 ①号室」を期待", orders[1].ShippingStreet)
+			}
+		})
+	}
+}
+
+// TestParseShopifyCSVLineEndings CRだけ、CRLF、LFのどの改行コードでも、また混在していても1行ずつ注文として読み込む
+func TestParseShopifyCSVLineEndings(t *testing.T) {
+	rows := []string{
+		"Name,Shipping Name,Shipping Zip",
+		"#1,山田太郎,150-0041",
+		"#2,佐藤花子,150-0042",
+		"#3,鈴木一郎,150-0043",
+	}
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{name: "CRだけ", csv: strings.Join(rows, "\r") + "\r"},
+		{name: "CRだけで最後の改行なし", csv: strings.Join(rows, "\r")},
+		{name: "CRLF", csv: strings.Join(rows, "\r\n") + "\r\n"},
+		{name: "LF", csv: strings.Join(rows, "\n") + "\n"},
+		{name: "混在", csv: rows[0] + "\r" + rows[1] + "\r\n" + rows[2] + "\n" + rows[3] + "\r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, orders, err := ParseShopifyCSV(strings.NewReader(tt.csv), InputEncodingUTF8, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"山田太郎", "佐藤花子", "鈴木一郎"}
+			if len(orders) != len(want) {
+				t.Fatalf("注文 = %d件、%d件を期待", len(orders), len(want))
+			}
+			for i, o := range orders {
+				if o.ShippingName != want[i] || o.ShippingZip != fmt.Sprintf("150-004%d", i+1) {
+					t.Errorf("%d件目の注文 = %q %q、%q 150-004%dを期待", i+1, o.ShippingName, o.ShippingZip, want[i], i+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Reconciliation 入力の行から送り状になるまでの段階ごとの件数
+// どの段階で注文が減ったかを実行の最後に表示する
+type Reconciliation struct {
+	Input    int // 入力ファイルから読み込んだ行の数
+	Merged   int // 注文番号ごとにまとめた後の注文の数
+	Filtered int // 前回のエクスポート、-include-orders・-exclude-orders、保留のタグ、-limitで絞り込んだ後の注文の数
+	Labels   int // 書き込んだ送り状の枚数。複数箱の注文は箱ごとに数える
+	Skipped  int // 送り状にできずスキップした注文の数
+}
+
+// String 「入力50件 / 重複統合後45件 / フィルタ後42件 / 有効ラベル40件 / スキップ2件」の形式にする
+func (r Reconciliation) String() string {
+	return fmt.Sprintf(localize("入力%d件 / 重複統合後%d件 / フィルタ後%d件 / 有効ラベル%d件 / スキップ%d件", "input %d / merged %d / filtered %d / labels %d / skipped %d"),
+		r.Input, r.Merged, r.Filtered, r.Labels, r.Skipped)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// RejectedOrder 送り状にできずスキップした注文
+type RejectedOrder struct {
+	Name string // 注文番号
+	Err  error  // スキップした理由
+	// Order スキップした注文データ。直し方の提案に使う
+	Order *ShopifyOrder
+}
+
+// ErrOrdersSkipped 送り状にできずスキップした注文がある。終了コード2になる
+var ErrOrdersSkipped = errors.New("スキップした注文があります")
+
+// ErrConversionPanic 注文の変換中にパニックが起きた。その注文だけをスキップする
+var ErrConversionPanic = errors.New("変換中に予期しないエラーが発生しました")
+
+// SortRejectedOrders スキップした注文を注文番号順に並べ替える
+// "#999" と "#1000" のような番号は数値として比較する
+func SortRejectedOrders(rejects []*RejectedOrder) {
+	sort.SliceStable(rejects, func(i, j int) bool {
+		return lessOrderName(rejects[i].Name, rejects[j].Name)
+	})
+}
+
+func lessOrderName(a, b string) bool {
+	na, errA := strconv.Atoi(NormalizeOrderName(a))
+	nb, errB := strconv.Atoi(NormalizeOrderName(b))
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+// logRejectedOrders スキップした注文を注文番号順にまとめてログに出力する
+// stripPrefixがtrueの場合は注文番号の先頭の「#」を取り除いて出力する
+// suggestがnilでない場合は、理由の後に直し方の提案を出力する
+func logRejectedOrders(rejects []*RejectedOrder, stripPrefix bool, suggest func(*RejectedOrder) string) {
+	SortRejectedOrders(rejects)
+	for _, r := range rejects {
+		name := r.Name
+		if stripPrefix {
+			name = NormalizeOrderName(name)
+		}
+		warnf(localize("注文番号:%s エラー:%s\n", "order:%s error:%s\n"), name, localizeError(r.Err))
+		auditLog.Log(logLevelWarn, r.Name, "skip", r.Err.Error())
+		if suggest == nil {
+			continue
+		}
+		if s := suggest(r); s != "" {
+			warnf("  → %s\n", s)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"html/template"
+	"os"
+)
+
+// reportTemplate 検証結果のHTMLレポートのテンプレート
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>送り状の検証結果 {{.Date}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+tr.ok td.status { background: #d8f5d8; color: #1a6b1a; }
+tr.ng td.status { background: #f8d7d7; color: #a11; }
+</style>
+</head>
+<body>
+<h1>送り状の検証結果 {{.Date}}</h1>
+<p>送り状: {{.Total}}件 / 問題なし: {{.Valid}}件 / 要確認: {{.Invalid}}件</p>
+<table>
+<tr><th>注文番号</th><th>状態</th><th>お届け先</th><th>住所</th><th>内容品</th><th>メッセージ</th></tr>
+{{range .Labels}}<tr class="{{if .Valid}}ok{{else}}ng{{end}}">
+<td>{{.OrderName}}</td>
+<td class="status">{{if .Valid}}OK{{else}}NG{{end}}</td>
+<td>{{.Label.ShippingName}} {{.Label.ShippingNameTitle}}</td>
+<td>〒{{.Label.ShippingZip}} {{.Label.ShippingAddress1}} {{.Label.ShippingAddress2}} {{.Label.ShippingAddress3}} {{.Label.ShippingAddress4}}</td>
+<td>{{.Label.ShippingContents}}</td>
+<td>{{.Error}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteValidationReport 送り状ごとの検証結果を、共有しやすいHTMLの表として書き込む
+// 行ごとに問題なし・要確認を色分けし、要確認の送り状には検証エラーを表示する
+func WriteValidationReport(filename string, previews []*PreviewLabel) error {
+	data := struct {
+		Date                  string
+		Total, Valid, Invalid int
+		Labels                []*PreviewLabel
+	}{Date: now().Format("2006-01-02 15:04"), Total: len(previews), Labels: previews}
+	for _, p := range previews {
+		if p.Valid {
+			data.Valid++
+		} else {
+			data.Invalid++
+		}
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := reportTemplate.Execute(f, data); err != nil {
+		return err
+	}
+	return f.Close()
+}
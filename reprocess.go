@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ReprocessClickpostShippingLabels 出力済みの送り状発行用CSVを読み込み、変換オプションの正規化をかけ直して検証し、同じファイルに書き直す
+// アップロードで弾かれたファイルだけを作り直すのに使う。検証に通らない行は除いて、その行の問題を返す
+func ReprocessClickpostShippingLabels(filename string, opts ConvertOptions, eopts ExportOptions) ([]string, error) {
+	_, labels, err := ReadClickpostShippingLabels(filename)
+	if err != nil {
+		return nil, err
+	}
+	var valid []*ClickpostShippingLabel
+	var problems []string
+	for i, label := range labels {
+		normalizeLabel(label, opts)
+		if err := opts.carrier().Validate(label); err != nil {
+			// ヘッダー行の分を足して、元のファイル上の行番号で表示する
+			problems = append(problems, fmt.Sprintf("%d行目: %v", i+2, err))
+			continue
+		}
+		valid = append(valid, label)
+	}
+	// 書き込みに失敗しても元のファイルが残るよう、一時ファイルに書いてから置き換える
+	if err := writeLabels(filename, valid, eopts); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// normalizeLabel 送り状に残っている値に、注文データがなくても適用できる正規化をかける
+func normalizeLabel(l *ClickpostShippingLabel, opts ConvertOptions) {
+	if opts.StripHonorific {
+		if stripped, h := opts.stripHonorific(l.ShippingName); h != "" {
+			l.ShippingName = stripped
+			if h == "御中" {
+				l.ShippingNameTitle = h
+			}
+		}
+	}
+	if opts.ArabicNumerals {
+		for _, p := range []*string{&l.ShippingAddress1, &l.ShippingAddress2, &l.ShippingAddress3, &l.ShippingAddress4} {
+			*p = NormalizeKanjiNumerals(*p)
+		}
+	}
+	if opts.SanitizeControlChars {
+		sanitizeControlChars(l)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// roomPattern Shipping Address2の末尾の部屋番号。「Room 301」「Suite 800」「#301」「３０１号室」のような書き方と、その前の建物名に分ける
+var roomPattern = regexp.MustCompile(`^(.*?)[\s　]*((?i:room|rm\.?|suite|ste\.?|apt\.?|unit)|[#＃])?[\s　]*([0-9０-９]+)[\s　]*(号室)?$`)
+
+// NormalizeRoomNumber Shipping Address2の部屋番号を「301号室」の形にそろえる。部屋番号がない場合は元の値とfalseを返す
+// 「301」のように番号だけの欄と、Room・Suiteなどの目印か号室がある番号だけを部屋番号とみなす。「渋谷マンション301」のような目印のない番号は変えない
+// 「号室」を付けるとmaxLen文字を超える場合は付けずに番号だけにし、それでも超える場合は変えない。maxLenが0の場合は上限なし
+func NormalizeRoomNumber(s string, maxLen int) (string, bool) {
+	m := roomPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return s, false
+	}
+	building, marker, number, suffix := m[1], m[2], width.Fold.String(m[3]), m[4]
+	if marker == "" && suffix == "" && building != "" {
+		return s, false
+	}
+	for _, normalized := range []string{building + number + "号室", building + number} {
+		if normalized == s {
+			return s, false
+		}
+		if maxLen == 0 || utf8.RuneCountInString(normalized) <= maxLen {
+			return normalized, true
+		}
+	}
+	return s, false
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+// TestNormalizeRoomNumber Room・Suiteなどの目印や全角数字の部屋番号を「301号室」の形にそろえ、上限の文字数に収まらなければ号室を付けない
+func TestNormalizeRoomNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		maxLen int
+		want   string
+		wantOK bool
+	}{
+		{name: "番号だけ", input: "301", want: "301号室", wantOK: true},
+		{name: "Room", input: "Room 301", want: "301号室", wantOK: true},
+		{name: "小文字のrm.", input: "rm. 301", want: "301号室", wantOK: true},
+		{name: "Suite", input: "Suite 800", want: "800号室", wantOK: true},
+		{name: "Apt", input: "Apt 12", want: "12号室", wantOK: true},
+		{name: "全角数字の号室", input: "３０１号室", want: "301号室", wantOK: true},
+		{name: "全角の＃", input: "＃３０１", want: "301号室", wantOK: true},
+		{name: "建物名とRoom", input: "渋谷マンション Room 301", want: "渋谷マンション301号室", wantOK: true},
+		{name: "そろっている", input: "301号室", want: "301号室"},
+		{name: "目印のない建物名の番号", input: "渋谷マンション301", want: "渋谷マンション301"},
+		{name: "部屋番号なし", input: "渋谷マンション", want: "渋谷マンション"},
+		{name: "号室を付けると上限を超える", input: "渋谷グランドマンション Room 301", maxLen: 15, want: "渋谷グランドマンション301", wantOK: true},
+		{name: "番号だけでも上限を超える", input: "渋谷グランドマンション Room 301", maxLen: 12, want: "渋谷グランドマンション Room 301"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeRoomNumber(tt.input, tt.maxLen)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("NormalizeRoomNumber(%q, %d) = %q %t、%q %tを期待", tt.input, tt.maxLen, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestNormalizeRoomLabel -normalize-roomの場合だけ、送り状の住所3行目の部屋番号をそろえる
+func TestNormalizeRoomLabel(t *testing.T) {
+	o := ShopifyOrder{ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "神南", ShippingAddress1: "1-2-3", ShippingAddress2: "Suite 800"}
+	opts := DefaultConvertOptions()
+	if got := o.ToClickpostShippingLabel(opts).ShippingAddress3; got != "Suite 800" {
+		t.Errorf("-normalize-roomなしの住所3行目 = %q、Suite 800のままを期待", got)
+	}
+	opts.NormalizeRoom = true
+	if got := o.ToClickpostShippingLabel(opts).ShippingAddress3; got != "800号室" {
+		t.Errorf("住所3行目 = %q、800号室を期待", got)
+	}
+}
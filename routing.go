@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseWeight Shopifyの「Total Weight」（グラム）を数値にする。空欄や数値として読めない場合はfalse
+func parseWeight(s string) (float64, bool) {
+	w, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return w, err == nil && w >= 0
+}
+
+// SplitOrdersByWeight 重さがthresholdグラム以下の注文と、それより重い注文に分ける
+// 重さが空欄や数値として読めない注文は軽い注文に含め、確認できるようunknownにも返す。どちらも入力の順序を保つ
+func SplitOrdersByWeight(orders []*ShopifyOrder, threshold float64) (light, heavy, unknown []*ShopifyOrder) {
+	for _, o := range orders {
+		w, ok := parseWeight(o.TotalWeight)
+		switch {
+		case !ok:
+			unknown = append(unknown, o)
+			light = append(light, o)
+		case w > threshold:
+			heavy = append(heavy, o)
+		default:
+			light = append(light, o)
+		}
+	}
+	return light, heavy, unknown
+}
+
+// ErrMixedCarriers 1つのチャンクに別の配送業者に振り分けた注文が混ざっている。振り分けの誤りなので、そのチャンクのファイルは書き込まない
+var ErrMixedCarriers = errors.New("1つのファイルに別の配送業者の注文が混ざっています")
+
+// CarrierRoute 注文を振り分ける配送業者の名前を返す関数
+type CarrierRoute func(o *ShopifyOrder) string
+
+// WeightRoute SplitOrdersByWeightと同じ基準で、重さがthresholdグラムを超える注文をheavy、それ以外をlightの配送業者に振り分ける
+func WeightRoute(threshold float64, light, heavy string) CarrierRoute {
+	return func(o *ShopifyOrder) string {
+		if w, ok := parseWeight(o.TotalWeight); ok && w > threshold {
+			return heavy
+		}
+		return light
+	}
+}
+
+// CheckChunkCarriers 各チャンクの注文がすべてcarrierに振り分けた注文か確かめる。routeがnilの場合は振り分けていないので確かめない
+// 振り分けの誤りで、例えばクリックポストのファイルにゆうパックの注文が入らないよう、ファイルを書き込む前に確かめる
+func CheckChunkCarriers(chunks [][]*ShopifyOrder, route CarrierRoute, carrier string) error {
+	if route == nil {
+		return nil
+	}
+	for i, chunk := range chunks {
+		for _, o := range chunk {
+			if c := route(o); c != carrier {
+				return fmt.Errorf("%w: %sの番号%dのファイルに%sの注文%sがあります", ErrMixedCarriers, carrier, i, c, o.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// carrierFilenameFormat チャンクごとの出力ファイル名の書式の先頭の「clickpost」を配送業者名に置き換える
+func carrierFilenameFormat(filenameFormat string, c *Carrier) string {
+	return c.Name + strings.TrimPrefix(filenameFormat, "clickpost")
+}
+
+// PlanCarrierChunks 注文を別の配送業者の検証ルールで検証・分割し、書き込む前に出力ファイル数を確かめる
+// 重さで振り分けた注文のように、1回の実行で2つ目の配送業者のファイルを作る場合に使う
+// routeがnilでなければ、どのチャンクもconfigの配送業者に振り分けた注文だけか確かめる
+func PlanCarrierChunks(orders []*ShopifyOrder, config *CarrierConfig, route CarrierRoute, mode ChunkMode, maxFiles int, opts ConvertOptions) ([][]*ShopifyOrder, []*RejectedOrder, error) {
+	opts.Carrier = config.Carrier
+	var rejects []*RejectedOrder
+	if !opts.KeepPlaceholders {
+		orders, rejects = SelectValidOrders(orders, opts)
+	}
+	chunks := ChunkShopifyOrdersBy(mode, orders, config.Carrier.MaxLabels)
+	if len(chunks) > maxFiles {
+		return nil, rejects, fmt.Errorf("%sの出力ファイル数が上限を超えています。%d件のファイルが作成されます（上限%d件）", config.Carrier.Name, len(chunks), maxFiles)
+	}
+	if err := CheckChunkCarriers(chunks, route, config.Carrier.Name); err != nil {
+		return nil, rejects, err
+	}
+	return chunks, rejects, nil
+}
+
+// ExportCarrierChunks PlanCarrierChunksで分割したチャンクを、configの配送業者の列でチャンクごとのファイルにエクスポートする
+func ExportCarrierChunks(chunks [][]*ShopifyOrder, config *CarrierConfig, filenameFormat string, parallel int, opts ConvertOptions, eopts ExportOptions) []*ChunkResult {
+	opts.Carrier, eopts.Carrier = config.Carrier, config.Carrier
+	eopts.Template = config.Template
+	return ExportChunks(chunks, filenameFormat, parallel, opts, eopts)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRoutedOrdersCSV 軽い注文light件と、重い注文または局留めの注文extra件の入力用CSVを書き込む
+func writeRoutedOrdersCSV(t *testing.T, filename string, light, extra int, weight, street string) {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("Name,Shipping Name,Shipping Street,Shipping Address1,Shipping City,Shipping Zip,Shipping Province,Total Weight\n")
+	for i := 1; i <= light+extra; i++ {
+		w, s := "500", "神南1-2-3"
+		if i > light {
+			w, s = weight, street
+		}
+		fmt.Fprintf(&b, "#%d,山田%d郎,%s,,渋谷区,150-0041,東京都,%s\n", i, i, s, w)
+	}
+	if err := os.WriteFile(filename, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunChecksAllGroupsBeforeWriting 重い注文や局留めの注文のファイルも、クリックポストのファイル数を確かめるまで書き込まない
+func TestRunChecksAllGroupsBeforeWriting(t *testing.T) {
+	const carriers = `{"carriers": {"yupack": {"max_labels": 30, "fields": [{"field": "ShippingZip", "code": "zip", "label": "郵便番号", "required": true}], "columns": [{"header": "郵便番号", "field": "ShippingZip"}]}}}`
+	tests := []struct {
+		name   string
+		weight string
+		street string
+		flags  map[string]string
+	}{
+		{
+			name:   "重さで振り分け",
+			weight: "5000",
+			street: "神南1-2-3",
+			flags:  map[string]string{"carriers": "carriers.json", "weight-threshold": "2000", "heavy-carrier": "yupack", "max-files": "2"},
+		},
+		{
+			name:   "局留めを別のファイルに",
+			weight: "500",
+			street: "渋谷郵便局留",
+			flags:  map[string]string{"special-address": "separate", "max-files": "2"},
+		},
+		{
+			name:   "-parallelが0",
+			weight: "500",
+			street: "渋谷郵便局留",
+			flags:  map[string]string{"special-address": "separate", "parallel": "0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("carriers.json", []byte(carriers), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			// 軽い注文の90件はクリックポストの3ファイルになる
+			writeRoutedOrdersCSV(t, "orders.csv", 90, 5, tt.weight, tt.street)
+			in = stringsFlag{"orders.csv"}
+			t.Cleanup(func() { in = nil })
+			setFlags(t, tt.flags)
+			captureLog(t)
+
+			if err := run(); err == nil {
+				t.Fatal("runがエラーになりません")
+			}
+			files, err := filepath.Glob("*-labels-*.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) > 0 {
+				t.Errorf("エラーで終了したのにファイルがあります: %v", files)
+			}
+		})
+	}
+}
+
+// TestCheckChunkCarriers 重さでゆうパックに振り分ける注文がクリックポストのチャンクに混ざっていれば、注文番号を付けてErrMixedCarriersにする
+func TestCheckChunkCarriers(t *testing.T) {
+	route := WeightRoute(2000, "clickpost", "yupack")
+	orders := streamTestOrders(5)
+	for _, o := range orders {
+		o.TotalWeight = "500"
+	}
+	orders[3].TotalWeight = "5000"
+	mixed := [][]*ShopifyOrder{orders[:2], orders[2:]}
+
+	err := CheckChunkCarriers(mixed, route, "clickpost")
+	if !errors.Is(err, ErrMixedCarriers) {
+		t.Fatalf("CheckChunkCarriers = %v、ErrMixedCarriersを期待", err)
+	}
+	if want := "clickpostの番号1のファイルにyupackの注文#4があります"; !strings.Contains(err.Error(), want) {
+		t.Errorf("エラー = %v、「%s」を期待", err, want)
+	}
+	light := [][]*ShopifyOrder{orders[:3], {orders[4]}}
+	if err := CheckChunkCarriers(light, route, "clickpost"); err != nil {
+		t.Errorf("軽い注文だけのCheckChunkCarriers = %v、nilを期待", err)
+	}
+	if err := CheckChunkCarriers([][]*ShopifyOrder{{orders[3]}}, route, "yupack"); err != nil {
+		t.Errorf("重い注文だけのCheckChunkCarriers = %v、nilを期待", err)
+	}
+	if err := CheckChunkCarriers(mixed, nil, "clickpost"); err != nil {
+		t.Errorf("振り分けていないCheckChunkCarriers = %v、nilを期待", err)
+	}
+}
+
+// TestPlanCarrierChunksMixedCarriers 別の配送業者に振り分けた注文を渡すと、チャンクを返さずにErrMixedCarriersにする
+func TestPlanCarrierChunksMixedCarriers(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "carriers.json")
+	const carriers = `{"carriers": {"yupack": {"max_labels": 30, "fields": [{"field": "ShippingZip", "code": "zip", "label": "郵便番号", "required": true}]}}}`
+	if err := os.WriteFile(filename, []byte(carriers), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := LoadCarriers(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := streamTestOrders(3)
+	for _, o := range orders {
+		o.TotalWeight = "5000"
+	}
+	orders[1].TotalWeight = "500"
+	route := WeightRoute(2000, "clickpost", "yupack")
+	chunks, _, err := PlanCarrierChunks(orders, configs["yupack"], route, ChunkModeGreedy, 10, DefaultConvertOptions())
+	if !errors.Is(err, ErrMixedCarriers) || chunks != nil {
+		t.Errorf("PlanCarrierChunks = %d件のチャンク %v、ErrMixedCarriersを期待", len(chunks), err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// LabelRule 名前の付いた送り状の検証ルール
+// 私書箱の住所を断る、郵便番号の地域を限るなど、ストアごとの検証をライブラリの利用者が追加するために使う
+type LabelRule struct {
+	Name string // ルールの名前。RemoveRuleで指定する。項目ごとの検証ルールはエラーコードの接頭辞（zip、address1など）
+	// Check 送り状がルールを満たさない場合に、エラーコード付きの*ValidationErrorを返す。満たす場合はnil
+	Check func(l *ClickpostShippingLabel) error
+}
+
+// Rules 配送業者の検証ルールを検証する順に返す。項目ごとの検証ルールの後に、追加のルールが続く
+func (c *Carrier) Rules() []LabelRule {
+	rules := make([]LabelRule, 0, len(c.Fields)+len(c.ExtraRules))
+	for _, r := range c.Fields {
+		r := r
+		rules = append(rules, LabelRule{Name: r.Code, Check: func(l *ClickpostShippingLabel) error {
+			return c.validateField(r, reflect.ValueOf(l).Elem().FieldByName(r.Field).String())
+		}})
+	}
+	return append(rules, c.ExtraRules...)
+}
+
+// AddRule 追加の検証ルールを登録する。同じ名前のルールがある場合はエラーを返す
+func (c *Carrier) AddRule(r LabelRule) error {
+	if r.Name == "" || r.Check == nil {
+		return fmt.Errorf("検証ルールには名前と検証する関数を指定してください")
+	}
+	if slices.ContainsFunc(c.Rules(), func(existing LabelRule) bool { return existing.Name == r.Name }) {
+		return fmt.Errorf("検証ルール「%s」はすでに%sに登録されています", r.Name, c.Name)
+	}
+	c.ExtraRules = append(c.ExtraRules, r)
+	return nil
+}
+
+// RemoveRule 名前の検証ルールを取り除く。項目ごとの検証ルールを取り除くと、その項目の必須・文字数の検証もしなくなる
+// 取り除いた場合はtrueを返す
+func (c *Carrier) RemoveRule(name string) bool {
+	if i := slices.IndexFunc(c.Fields, func(r FieldRule) bool { return r.Code == name }); i >= 0 {
+		c.Fields = slices.Delete(slices.Clone(c.Fields), i, i+1)
+		return true
+	}
+	if i := slices.IndexFunc(c.ExtraRules, func(r LabelRule) bool { return r.Name == name }); i >= 0 {
+		c.ExtraRules = slices.Delete(slices.Clone(c.ExtraRules), i, i+1)
+		return true
+	}
+	return false
+}
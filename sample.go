@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+
+	"github.com/gocarina/gocsv"
+)
+
+// sampleShopifyOrder テンプレートに載せる記入例
+var sampleShopifyOrder = &ShopifyOrder{
+	Name:             "#1001",
+	ShippingName:     "山田太郎",
+	ShippingStreet:   "神南",
+	ShippingAddress1: "1-2-3",
+	ShippingAddress2: "渋谷マンション301",
+	ShippingCity:     "渋谷区",
+	ShippingZip:      "150-0041",
+	ShippingProvince: "東京都",
+	BoxCount:         "1",
+}
+
+// WriteSampleShopifyOrders 入力用CSVのテンプレートを書き込む
+// ヘッダー行はShopifyOrderのcsvタグから作るので、読み込める列と常に一致する
+func WriteSampleShopifyOrders(filename string) error {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	if err := gocsv.MarshalFile(&[]*ShopifyOrder{sampleShopifyOrder}, outFile); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SheetFill 送り状を台紙の面に並べたい順番
+type SheetFill string
+
+const (
+	// SheetFillRows 左から右へ、上の行から下の行へ。プリンターが面に印刷する順番と同じなので並べ替えない
+	SheetFillRows SheetFill = "rows"
+	// SheetFillColumns 上から下へ、左の列から右の列へ
+	SheetFillColumns SheetFill = "columns"
+)
+
+// SheetLayout 送り状を印刷する台紙の面の並び
+type SheetLayout struct {
+	Rows    int // 1枚の台紙の面の行数
+	Columns int // 1枚の台紙の面の列数
+	Fill    SheetFill
+}
+
+// ParseSheetLayout 「2x5」の形式の行数×列数と、面に並べたい順番を読む
+func ParseSheetLayout(layout, fill string) (*SheetLayout, error) {
+	rows, columns, ok := strings.Cut(strings.NewReplacer("×", "x", "X", "x").Replace(layout), "x")
+	r, errR := strconv.Atoi(strings.TrimSpace(rows))
+	c, errC := strconv.Atoi(strings.TrimSpace(columns))
+	if !ok || errR != nil || errC != nil || r < 1 || c < 1 {
+		return nil, fmt.Errorf("-sheet-layoutは「2x5」のように行数x列数で指定してください: %s", layout)
+	}
+	switch f := SheetFill(fill); f {
+	case SheetFillRows, SheetFillColumns:
+		return &SheetLayout{Rows: r, Columns: c, Fill: f}, nil
+	}
+	return nil, fmt.Errorf("-sheet-fillはrowsかcolumnsを指定してください: %s", fill)
+}
+
+// Arrange 台紙の面に印刷された送り状がFillの順番に並ぶよう、ファイルの行を並べ替える
+// プリンターはファイルの行を台紙の左上から右へ、行ごとに下へ印刷するので、その位置にFillの順番で何番目になる送り状を置く
+// 最後の台紙が埋まらない場合は、印刷される面の中でFillの順番にする。どの送り状もちょうど1回ずつ現れる
+func (s *SheetLayout) Arrange(labels []*ClickpostShippingLabel) []*ClickpostShippingLabel {
+	if s == nil || s.Fill == SheetFillRows {
+		return labels
+	}
+	arranged := make([]*ClickpostShippingLabel, len(labels))
+	size := s.Rows * s.Columns
+	for start := 0; start < len(labels); start += size {
+		page := labels[start:min(start+size, len(labels))]
+		// 印刷される面を、上から下へ、左の列から右の列への順に並べる
+		positions := make([]int, len(page))
+		for i := range positions {
+			positions[i] = i
+		}
+		sort.SliceStable(positions, func(i, j int) bool {
+			pi, pj := positions[i], positions[j]
+			if pi%s.Columns != pj%s.Columns {
+				return pi%s.Columns < pj%s.Columns
+			}
+			return pi/s.Columns < pj/s.Columns
+		})
+		for i, p := range positions {
+			arranged[start+p] = page[i]
+		}
+	}
+	return arranged
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// sheetTestLabels 氏名が「1」から「n」の送り状
+func sheetTestLabels(n int) []*ClickpostShippingLabel {
+	labels := make([]*ClickpostShippingLabel, n)
+	for i := range labels {
+		labels[i] = &ClickpostShippingLabel{ShippingName: fmt.Sprint(i + 1)}
+	}
+	return labels
+}
+
+// sheetLabelNames 送り状の氏名を並びの順に返す
+func sheetLabelNames(labels []*ClickpostShippingLabel) []string {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.ShippingName
+	}
+	return names
+}
+
+// TestSheetLayoutArrange 2行×5列の台紙で、印刷される面が上から下へ、左の列から右の列への順になるようファイルの行を並べ替える
+func TestSheetLayoutArrange(t *testing.T) {
+	tests := []struct {
+		name string
+		fill string
+		n    int
+		want []string
+	}{
+		// 台紙の上の行に1・3・5・7・9、下の行に2・4・6・8・10が印刷される
+		{name: "1枚の台紙", fill: "columns", n: 10, want: []string{"1", "3", "5", "7", "9", "2", "4", "6", "8", "10"}},
+		{name: "2枚目の台紙が埋まらない", fill: "columns", n: 13, want: []string{"1", "3", "5", "7", "9", "2", "4", "6", "8", "10", "11", "12", "13"}},
+		// 面が埋まらない台紙では、印刷される上の行の5面と下の行の2面を列の順に並べる
+		{name: "台紙が埋まらない", fill: "columns", n: 7, want: []string{"1", "3", "5", "6", "7", "2", "4"}},
+		{name: "rowsは並べ替えない", fill: "rows", n: 10, want: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := ParseSheetLayout("2x5", tt.fill)
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels := sheetTestLabels(tt.n)
+			got := layout.Arrange(labels)
+			if names := sheetLabelNames(got); !slices.Equal(names, tt.want) {
+				t.Errorf("並べ替えた送り状 = %v、%vを期待", names, tt.want)
+			}
+			// どの送り状もちょうど1回ずつ現れる
+			seen := map[*ClickpostShippingLabel]bool{}
+			for _, l := range got {
+				seen[l] = true
+			}
+			if len(seen) != len(labels) {
+				t.Errorf("送り状 = %d種類、%d種類を期待", len(seen), len(labels))
+			}
+		})
+	}
+	var none *SheetLayout
+	if names := sheetLabelNames(none.Arrange(sheetTestLabels(3))); !slices.Equal(names, []string{"1", "2", "3"}) {
+		t.Errorf("-sheet-layoutなしの送り状 = %v、そのままを期待", names)
+	}
+}
+
+// TestParseSheetLayout 「行数x列数」と並べる順番を読み、形式の誤りはエラーにする
+func TestParseSheetLayout(t *testing.T) {
+	for _, s := range []string{"2x5", "2X5", "2×5", " 2 x 5 "} {
+		layout, err := ParseSheetLayout(s, "columns")
+		if err != nil || layout.Rows != 2 || layout.Columns != 5 {
+			t.Errorf("ParseSheetLayout(%q) = %+v %v、2行5列を期待", s, layout, err)
+		}
+	}
+	for _, s := range []string{"", "10", "0x5", "2x", "ax5"} {
+		if _, err := ParseSheetLayout(s, "columns"); err == nil {
+			t.Errorf("ParseSheetLayout(%q)がエラーになりません", s)
+		}
+	}
+	if _, err := ParseSheetLayout("2x5", "diagonal"); err == nil {
+		t.Error("-sheet-fillの誤りがエラーになりません")
+	}
+}
+
+// TestRunSheetLayout -sheet-layout 2x5でファイルごとに送り状の行を並べ替える
+func TestRunSheetLayout(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeOrdersCSV(t, "orders.csv", 12, "")
+	in = stringsFlag{"orders.csv"}
+	t.Cleanup(func() { in = nil })
+	setFlags(t, map[string]string{"sheet-layout": "2x5"})
+	captureLog(t)
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	_, labels, err := ReadClickpostShippingLabels("clickpost-shipping-labels-0.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, l := range labels {
+		got = append(got, l.ShippingName)
+	}
+	want := []string{"山田1郎", "山田3郎", "山田5郎", "山田7郎", "山田9郎", "山田2郎", "山田4郎", "山田6郎", "山田8郎", "山田10郎", "山田11郎", "山田12郎"}
+	if !slices.Equal(got, want) {
+		t.Errorf("送り状の氏名 = %v、%vを期待", got, want)
+	}
+}
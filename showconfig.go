@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ConfigEntry 解決済みの設定の1項目
+type ConfigEntry struct {
+	Name  string
+	Value string
+}
+
+// ResolvedConfig フラグと設定ファイルから決まった、実際に使う設定の一覧
+// 秘密の値を含む項目を追加する場合は、ここで表示する値を伏せる
+func ResolvedConfig(inputs []string, ienc InputEncoding, opts ConvertOptions, eopts ExportOptions, outDir string) []ConfigEntry {
+	columns := "クリックポストの列"
+	if eopts.Template != nil {
+		var headers []string
+		for _, c := range eopts.Template.Columns {
+			headers = append(headers, c.Header)
+		}
+		columns = strings.Join(headers, ",")
+	}
+	if outDir == "" {
+		outDir = "."
+	}
+	var limits []string
+	for _, r := range opts.carrier().Fields {
+		if r.MaxLen > 0 {
+			limits = append(limits, fmt.Sprintf("%s=%d", r.Code, r.MaxLen))
+		}
+	}
+	sort.Strings(limits)
+	return []ConfigEntry{
+		{"入力", strings.Join(inputs, ", ")},
+		{"入力の文字コード", string(ienc)},
+		{"配送業者", opts.carrier().Name},
+		{"1ファイルの送り状の上限", fmt.Sprint(eopts.carrier().MaxLabels)},
+		{"文字数の上限", strings.Join(limits, ",")},
+		{"分割方法", *chunkMode},
+		{"出力の文字コード", string(eopts.Encoding)},
+		{"出力の改行コード", string(eopts.LineEnding)},
+		{"出力の列", columns},
+		{"出力先", outDir},
+		{"ファイル名の識別子", *filenameStamp},
+		{"住所の書式", string(opts.AddressStyle)},
+		{"内容品", JoinClickpostContents(opts.contentsItems())},
+		{"ハイフンの正規化", onOff(opts.NormalizeHyphens)},
+		{"敬称の除去", onOff(opts.StripHonorific)},
+		{"会社名の御中", onOff(opts.CompanyFallback)},
+		{"住所の空の行を詰める", onOff(opts.CompactAddress)},
+		{"建物名の分割", onOff(opts.SplitBuilding)},
+		{"様方の分割", onOff(opts.SplitCareOf)},
+		{"漢数字の変換", onOff(opts.ArabicNumerals)},
+		{"制御文字", *controlChars},
+	}
+}
+
+// onOff 真偽値の設定の表示
+func onOff(b bool) string {
+	if b {
+		return "有効"
+	}
+	return "無効"
+}
+
+// WriteResolvedConfig 解決済みの設定を「項目: 値」の形式でwに出力する
+func WriteResolvedConfig(w io.Writer, entries []ConfigEntry) {
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s: %s\n", e.Name, e.Value)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrOrderNotFound -show-orderの注文番号の注文が入力にない
+var ErrOrderNotFound = errors.New("注文が見つかりません")
+
+// ShowOrder 1つの注文を送り状に変換・検証し、送り状に印字される列と検証エラーをwに出力する
+// お客様からの問い合わせで、すべての送り状を作らずに1件の印字を確かめるために使う
+// 列はeoptsの列の定義に従うので、-carrier-templateや-barcode-columnの列も書き込むとおりに表示する
+// 送り状にできる場合はtrueを返す
+func ShowOrder(w io.Writer, o *ShopifyOrder, opts ConvertOptions, eopts ExportOptions, mask bool) (bool, error) {
+	t := eopts.Template
+	if t == nil {
+		var err error
+		if t, err = ClickpostTemplate(nil); err != nil {
+			return false, err
+		}
+	}
+	var problems []error
+	labels, err := o.ToClickpostShippingLabels(opts)
+	if err != nil {
+		labels, problems = []*ClickpostShippingLabel{o.ToClickpostShippingLabel(opts)}, []error{err}
+	}
+	fmt.Fprintf(w, "注文番号:%s（送り状%d枚）\n", o.Name, len(labels))
+	for i, l := range labels {
+		if err == nil {
+			problems = append(problems, opts.carrier().ValidateAll(l)...)
+		}
+		if mask {
+			l = MaskClickpostShippingLabel(l)
+		}
+		if len(labels) > 1 {
+			fmt.Fprintf(w, "  送り状%d/%d:\n", i+1, len(labels))
+		} else {
+			fmt.Fprintln(w, "  送り状:")
+		}
+		record := t.Records([]*ClickpostShippingLabel{l})[1]
+		for j, c := range t.Columns {
+			fmt.Fprintf(w, "    %s: %q\n", c.Header, record[j])
+		}
+	}
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "  検証: 送り状にできます")
+		return true, nil
+	}
+	// 複数箱の注文は送り状ごとに同じエラーになることが多いので、1回だけ表示する
+	fmt.Fprintln(w, "  検証エラー:")
+	seen := map[string]bool{}
+	for _, p := range problems {
+		if msg := localizeError(p); !seen[msg] {
+			seen[msg] = true
+			fmt.Fprintf(w, "    %s\n", msg)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// addressBreakAfter 住所の区切りとみなす文字。この文字の後ろで行を分ける
+const addressBreakAfter = "都道府県市区町村郡"
+
+// isSingleFieldAddress 都道府県・市区町村・町名の欄が空欄で、住所が1つの欄にまとめて入力されているか
+func (s ShopifyOrder) isSingleFieldAddress() bool {
+	return strings.TrimSpace(s.ShippingProvince+s.ShippingCity+s.ShippingStreet) == "" &&
+		strings.TrimSpace(s.ShippingAddress1) != ""
+}
+
+// singleFieldAddressLines 1つの欄に入力された住所を、住所の行の文字数の上限で4行に分ける
+// Shipping Address2は建物名などの続きとして後ろにつなぐ
+func (s ShopifyOrder) singleFieldAddressLines(opts ConvertOptions) [4]string {
+	text := strings.TrimSpace(strings.TrimSpace(s.ShippingAddress1) + " " + strings.TrimSpace(s.ShippingAddress2))
+	source := "Shipping Address1+Shipping Address2（-address-style singleで文字数で分ける）"
+	if opts.ArabicNumerals {
+		if normalized := NormalizeKanjiNumerals(text); normalized != text {
+			text = normalized
+			source += "（漢数字を算用数字に変換）"
+		}
+	}
+	if opts.AbbreviateAddress {
+		if abbreviated := opts.abbreviateAddress(text); abbreviated != text {
+			text = abbreviated
+			source += "（-abbreviate-addressで略記）"
+		}
+	}
+	var lines [4]string
+	var maxLens []int
+	for _, field := range addressLineFields {
+		maxLens = append(maxLens, opts.carrier().maxLen(field))
+	}
+	for i, line := range SplitFreeTextAddress(text, maxLens) {
+		lines[i] = line
+		opts.trace(addressLineFields[i], "%s", source)
+	}
+	return lines
+}
+
+// SplitFreeTextAddress 1つの文字列の住所を、maxLensの行ごとの文字数の上限で分ける。上限が0の行は分けずに残りをすべて入れる
+// 行の上限までに空白、都道府県の後ろ、「市」「区」「町」「村」「郡」の後ろ、番地の後ろのような住所の区切りがあれば、最後の区切りで分ける
+// 区切りがない場合は上限の文字数で分ける。文字数は送り状の検証と同じく全角・半角を区別せず1文字と数える
+// すべての行に収まらない分は最後の行にまとめるので、検証で文字数の上限を超えたとしてスキップされる
+func SplitFreeTextAddress(s string, maxLens []int) []string {
+	r := []rune(strings.TrimSpace(s))
+	var lines []string
+	for len(r) > 0 && len(lines) < len(maxLens)-1 {
+		maxLen := maxLens[len(lines)]
+		if maxLen <= 0 || len(r) <= maxLen {
+			break
+		}
+		cut := lastAddressBreak(r, maxLen)
+		if line := strings.TrimSpace(string(r[:cut])); line != "" {
+			lines = append(lines, line)
+		}
+		r = []rune(strings.TrimSpace(string(r[cut:])))
+	}
+	if len(r) > 0 {
+		lines = append(lines, string(r))
+	}
+	return lines
+}
+
+// lastAddressBreak r[:maxLen]の中で最後の住所の区切りの位置。区切りがない場合はmaxLen
+func lastAddressBreak(r []rune, maxLen int) int {
+	breaks := addressBreaks(r)
+	for i := maxLen; i > 0; i-- {
+		if breaks[i] {
+			return i
+		}
+	}
+	return maxLen
+}
+
+// addressBreaks 住所の区切りの位置の集合。位置iはr[:i]とr[i:]の間を表す
+func addressBreaks(r []rune) map[int]bool {
+	breaks := map[int]bool{}
+	s := string(r)
+	// 「神南1-2-3 渋谷マンション301」の番地の後ろ
+	if m := banchiPattern.FindStringSubmatchIndex(s); m != nil {
+		breaks[len([]rune(s[:m[3]]))] = true
+	}
+	for i, c := range r {
+		if unicode.IsSpace(c) {
+			breaks[i] = true
+		} else if strings.ContainsRune(addressBreakAfter, c) {
+			breaks[i+1] = true
+		}
+	}
+	return breaks
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SpecialAddressMode 局留めや私書箱の住所の注文の扱い
+type SpecialAddressMode string
+
+const (
+	// SpecialAddressWarn 注意を表示して、ほかの注文と同じファイルに書き込む
+	SpecialAddressWarn SpecialAddressMode = "warn"
+	// SpecialAddressReject 送り状にせずスキップする
+	SpecialAddressReject SpecialAddressMode = "reject"
+	// SpecialAddressSeparate ほかの注文とは別のファイルに書き込む
+	SpecialAddressSeparate SpecialAddressMode = "separate"
+	// SpecialAddressIgnore 確かめない
+	SpecialAddressIgnore SpecialAddressMode = "ignore"
+)
+
+// ParseSpecialAddressMode 文字列から局留めや私書箱の住所の注文の扱いを返す
+func ParseSpecialAddressMode(s string) (SpecialAddressMode, error) {
+	switch mode := SpecialAddressMode(s); mode {
+	case SpecialAddressWarn, SpecialAddressReject, SpecialAddressSeparate, SpecialAddressIgnore:
+		return mode, nil
+	}
+	return "", fmt.Errorf("-special-addressはwarn、reject、separate、ignoreのいずれかを指定してください: %s", s)
+}
+
+// defaultSpecialAddressMarkers 局留めや私書箱の住所の目印のデフォルト。「局留」は「局留め」「郵便局留」も含む
+var defaultSpecialAddressMarkers = []string{"局留", "局止め", "私書箱", "P.O. Box", "PO Box"}
+
+// ParseSpecialAddressMarkers カンマ区切りの局留めや私書箱の住所の目印を読む
+func ParseSpecialAddressMarkers(s string) ([]string, error) {
+	var markers []string
+	for _, m := range strings.Split(s, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			markers = append(markers, m)
+		}
+	}
+	if len(markers) == 0 {
+		return nil, errors.New("-special-address-markersに目印を1つ以上指定してください")
+	}
+	return markers, nil
+}
+
+// specialAddressMarkers 局留めや私書箱の住所の目印。指定がなければデフォルトを返す
+func (o ConvertOptions) specialAddressMarkers() []string {
+	if len(o.SpecialAddressMarkers) == 0 {
+		return defaultSpecialAddressMarkers
+	}
+	return o.SpecialAddressMarkers
+}
+
+// specialAddressMarker 注文の住所に含まれる局留めや私書箱の目印を返す。含まない場合は空
+// 「PO Box」と「po box」のような英字の大文字・小文字は区別しない
+func (o ConvertOptions) specialAddressMarker(s ShopifyOrder) string {
+	address := strings.ToLower(strings.Join([]string{s.ShippingCity, s.ShippingStreet, s.ShippingAddress1, s.ShippingAddress2, s.ShippingCompany}, " "))
+	for _, m := range o.specialAddressMarkers() {
+		if strings.Contains(address, strings.ToLower(m)) {
+			return m
+		}
+	}
+	return ""
+}
+
+// SpecialAddressOrder 局留めや私書箱の住所の注文と、見つかった目印
+type SpecialAddressOrder struct {
+	Order  *ShopifyOrder
+	Marker string
+}
+
+// FindSpecialAddressOrders 局留めや私書箱の住所の注文を返す
+// 配送業者によっては受け付けず、送り状を作っても戻ってくるので、印刷する前に確かめられるようにする
+func FindSpecialAddressOrders(orders []*ShopifyOrder, opts ConvertOptions) []SpecialAddressOrder {
+	var found []SpecialAddressOrder
+	for _, o := range orders {
+		if m := opts.specialAddressMarker(*o); m != "" {
+			found = append(found, SpecialAddressOrder{Order: o, Marker: m})
+		}
+	}
+	return found
+}
+
+// SplitSpecialAddressOrders 局留めや私書箱の住所ではない注文と、その住所の注文に分ける。どちらも入力の順序を保つ
+func SplitSpecialAddressOrders(orders []*ShopifyOrder, opts ConvertOptions) (regular, special []*ShopifyOrder) {
+	for _, o := range orders {
+		if opts.specialAddressMarker(*o) != "" {
+			special = append(special, o)
+		} else {
+			regular = append(regular, o)
+		}
+	}
+	return regular, special
+}
+
+// specialAddressFilenameFormat 局留めや私書箱の住所の注文のファイル名の書式。チャンクごとの出力ファイル名の「shipping-labels」を置き換える
+func specialAddressFilenameFormat(filenameFormat string) string {
+	return strings.Replace(filenameFormat, "shipping-labels", "special-address-labels", 1)
+}
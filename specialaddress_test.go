@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSpecialAddressMarker 住所や会社名に含まれる局留めや私書箱の目印を見つける。英字の大文字・小文字は区別しない
+func TestSpecialAddressMarker(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   ShopifyOrder
+		markers []string
+		want    string
+	}{
+		{name: "目印なし", order: ShopifyOrder{ShippingStreet: "神南1-2-3", ShippingAddress2: "渋谷マンション301"}, want: ""},
+		{name: "局留め", order: ShopifyOrder{ShippingStreet: "渋谷郵便局留め"}, want: "局留"},
+		{name: "局止め", order: ShopifyOrder{ShippingAddress1: "渋谷郵便局止め"}, want: "局止め"},
+		{name: "私書箱", order: ShopifyOrder{ShippingAddress2: "私書箱12号"}, want: "私書箱"},
+		{name: "会社名の欄", order: ShopifyOrder{ShippingCompany: "渋谷郵便局留"}, want: "局留"},
+		{name: "英字の小文字", order: ShopifyOrder{ShippingAddress1: "po box 123"}, want: "PO Box"},
+		{name: "指定した目印", order: ShopifyOrder{ShippingAddress1: "営業所止め"}, markers: []string{"営業所止め"}, want: "営業所止め"},
+		{name: "指定した目印にない局留め", order: ShopifyOrder{ShippingStreet: "渋谷郵便局留め"}, markers: []string{"営業所止め"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			opts.SpecialAddressMarkers = tt.markers
+			if got := opts.specialAddressMarker(tt.order); got != tt.want {
+				t.Errorf("specialAddressMarker = %q、%qを期待", got, tt.want)
+			}
+		})
+	}
+	if _, err := ParseSpecialAddressMarkers(" , "); err == nil {
+		t.Error("目印が空の指定がエラーになりません")
+	}
+}
+
+// TestRunSpecialAddress -special-addressのwarn、reject、separateで、局留めの注文#2を注意、スキップ、別のファイルにする
+func TestRunSpecialAddress(t *testing.T) {
+	tests := []struct {
+		mode      string
+		wantErr   error
+		wantLog   string
+		wantFiles map[string]int // ファイル名と送り状の件数
+		wantIn    string         // #2の送り状を書き込むファイル。空の場合はどのファイルにも書き込まない
+	}{
+		{
+			mode:      "warn",
+			wantLog:   "注意: 局留めや私書箱の住所は配送業者が受け付けないことがあります: #2(佐藤花子)（「局留」）",
+			wantFiles: map[string]int{"clickpost-shipping-labels-0.csv": 3},
+			wantIn:    "clickpost-shipping-labels-0.csv",
+		},
+		{
+			mode:      "reject",
+			wantErr:   ErrOrdersSkipped,
+			wantLog:   "注文番号:#2 エラー:局留めや私書箱の住所は配送業者が受け付けないことがあるためスキップします",
+			wantFiles: map[string]int{"clickpost-shipping-labels-0.csv": 2},
+		},
+		{
+			mode:      "separate",
+			wantFiles: map[string]int{"clickpost-shipping-labels-0.csv": 2, "clickpost-special-address-labels-0.csv": 1},
+			wantIn:    "clickpost-special-address-labels-0.csv",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			csv := "Name,Shipping Name,Shipping Street,Shipping Address1,Shipping City,Shipping Zip,Shipping Province\n" +
+				"#1,山田太郎,神南1-2-3,,渋谷区,150-0041,東京都\n" +
+				"#2,佐藤花子,渋谷郵便局留め,,渋谷区,150-0002,東京都\n" +
+				"#3,鈴木一郎,宇田川町1-1,,渋谷区,150-0042,東京都\n"
+			if err := os.WriteFile("orders.csv", []byte(csv), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			in = stringsFlag{"orders.csv"}
+			t.Cleanup(func() { in = nil })
+			setFlags(t, map[string]string{"special-address": tt.mode})
+			logs := captureLog(t)
+
+			var err error
+			captureStdout(t, func() { err = run() })
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("runのエラー = %v、%vを期待", err, tt.wantErr)
+			}
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("ログに「%s」がありません:\n%s", tt.wantLog, logs)
+			}
+			files, err := filepath.Glob("clickpost-*.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tt.wantFiles) {
+				t.Errorf("ファイル = %v、%d個を期待", files, len(tt.wantFiles))
+			}
+			for filename, n := range tt.wantFiles {
+				_, labels, err := ReadClickpostShippingLabels(filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(labels) != n {
+					t.Errorf("%sの送り状 = %d件、%d件を期待", filename, len(labels), n)
+				}
+				found := false
+				for _, l := range labels {
+					found = found || l.ShippingName == "佐藤花子"
+				}
+				if found != (filename == tt.wantIn) {
+					t.Errorf("%sの局留めの注文#2の送り状 = %t、%tを期待", filename, found, !found)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// otherGroupKey -split-byの列が空欄の注文をまとめるグループ
+const otherGroupKey = "その他"
+
+// splitByZipPrefix -split-byで郵便番号の上3桁で分ける場合の名前
+const splitByZipPrefix = "zip-prefix"
+
+// OrderGroup -split-byで分けた注文のグループ
+type OrderGroup struct {
+	Key    string // グループの値。列が空欄の注文は「その他」
+	Orders []*ShopifyOrder
+}
+
+// ParseSplitBy -split-byの列から、注文をグループに分ける値を返す関数を作る
+// 列はShopifyの列名（Shipping Province）かShopifyOrderのフィールド名（ShippingProvince）で指定する。zip-prefixは郵便番号の上3桁
+func ParseSplitBy(column string) (func(*ShopifyOrder) string, error) {
+	if column == splitByZipPrefix {
+		return func(o *ShopifyOrder) string {
+			digits := strings.Map(func(r rune) rune {
+				if r >= '0' && r <= '9' {
+					return r
+				}
+				return -1
+			}, normalizeNumberField(o.ShippingZip))
+			if len(digits) < 3 {
+				return ""
+			}
+			return digits[:3]
+		}, nil
+	}
+	orderType := reflect.TypeOf(ShopifyOrder{})
+	for i := 0; i < orderType.NumField(); i++ {
+		f := orderType.Field(i)
+		if tag := f.Tag.Get("csv"); tag == "-" || (tag != column && f.Name != column) {
+			continue
+		}
+		return func(o *ShopifyOrder) string {
+			return strings.TrimSpace(reflect.ValueOf(o).Elem().Field(i).String())
+		}, nil
+	}
+	return nil, fmt.Errorf("-split-byにはShopifyの注文データの列名か%sを指定してください: %s", splitByZipPrefix, column)
+}
+
+// GroupOrdersBy 注文をkeyの値ごとのグループに分ける。グループは最初に現れた順で、「その他」は最後にする。グループの中の注文は入力の順序を保つ
+func GroupOrdersBy(orders []*ShopifyOrder, key func(*ShopifyOrder) string) []OrderGroup {
+	var groups []OrderGroup
+	index := map[string]int{}
+	var other []*ShopifyOrder
+	for _, o := range orders {
+		k := key(o)
+		if k == "" {
+			other = append(other, o)
+			continue
+		}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, OrderGroup{Key: k})
+		}
+		groups[i].Orders = append(groups[i].Orders, o)
+	}
+	if len(other) > 0 {
+		groups = append(groups, OrderGroup{Key: otherGroupKey, Orders: other})
+	}
+	return groups
+}
+
+// groupFilenameFormats グループごとの出力ファイル名の書式。チャンクごとの出力ファイル名の「shipping-labels」の後ろにグループの値を付ける
+// ファイル名に使えない文字は「_」にする。置き換えた結果が同じになるグループがあると上書きしてしまうのでエラーにする
+func groupFilenameFormats(filenameFormat string, groups []OrderGroup) ([]string, error) {
+	formats := make([]string, 0, len(groups))
+	seen := map[string]string{}
+	for _, g := range groups {
+		name := sanitizeFilenameKey(g.Key)
+		if prev, ok := seen[name]; ok {
+			return nil, fmt.Errorf("-split-byの値「%s」と「%s」が同じファイル名になります", prev, g.Key)
+		}
+		seen[name] = g.Key
+		formats = append(formats, strings.Replace(filenameFormat, "shipping-labels", "shipping-labels-"+name, 1))
+	}
+	return formats, nil
+}
+
+// sanitizeFilenameKey ファイル名に使えない文字と空白を「_」にする。書式の「%」も置き換える
+func sanitizeFilenameKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|%.`, r) || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// now 現在時刻を返す。出力ファイル名やレポートの日時に使う
+var now = time.Now
+
+// FilenameStamp 出力ファイル名に付ける識別子の種類
+type FilenameStamp string
+
+const (
+	// FilenameStampNone 識別子を付けない。clickpost-shipping-labels-0.csv
+	FilenameStampNone FilenameStamp = "none"
+	// FilenameStampTime 実行した日時を付ける。clickpost-shipping-labels-20240501-143000-0.csv
+	FilenameStampTime FilenameStamp = "time"
+	// FilenameStampRandom 実行ごとのランダムな8桁の英数字を付ける。clickpost-shipping-labels-1a2b3c4d-0.csv
+	FilenameStampRandom FilenameStamp = "random"
+)
+
+// ParseFilenameStamp 文字列から出力ファイル名の識別子の種類を返す
+func ParseFilenameStamp(s string) (FilenameStamp, error) {
+	switch stamp := FilenameStamp(s); stamp {
+	case FilenameStampNone, FilenameStampTime, FilenameStampRandom:
+		return stamp, nil
+	}
+	return "", fmt.Errorf("ファイル名の識別子はnone、time、randomのいずれかを指定してください: %s", s)
+}
+
+// clickpostFilenames 識別子を付けたチャンクごとの出力ファイル名の書式と、-single-fileの出力ファイル名を返す
+// 識別子は実行ごとに1回だけ決めるので、同じ実行のファイルはすべて同じ識別子になる
+func (s FilenameStamp) clickpostFilenames() (format, single string, err error) {
+	var token string
+	switch s {
+	case FilenameStampTime:
+		token = now().Format("20060102-150405")
+	case FilenameStampRandom:
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return "", "", err
+		}
+		token = hex.EncodeToString(b)
+	default:
+		return clickpostFilenameFormat, clickpostSingleFilename, nil
+	}
+	return "clickpost-shipping-labels-" + token + "-%d.csv", "clickpost-shipping-labels-" + token + ".csv", nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExportChunksStreaming 注文を1件ずつ変換・検証し、送り状が上限のmaxLabels枚に達するたびにファイルへ書き込む
+// 分割してから書き込むExportChunksと異なり、送り状はファイル1つ分しか持たず、結果のLabelsも空にするので、注文が多くてもメモリの使用量が増えない
+// 検証に通らない注文を除いてから先頭から詰めるgreedyの分割と同じファイルになる。-keep-placeholdersの場合は代わりの行も枠を使う
+// ファイルの数はすべての注文を変換するまで分からないので、maxFilesを超える場合は書き込み済みのファイルを削除してからエラーを返す
+func ExportChunksStreaming(orders []*ShopifyOrder, filenameFormat string, maxLabels, maxFiles int, opts ConvertOptions, eopts ExportOptions) ([]*ChunkResult, error) {
+	var results []*ChunkResult
+	var buffered []*ClickpostShippingLabel
+	var rejects []*RejectedOrder
+	flush := func() error {
+		i := len(results)
+		if i >= maxFiles {
+			removeChunkFiles(results)
+			return fmt.Errorf("出力ファイル数が上限を超えています。%d件目のファイルが作成されます（上限%d件）", i+1, maxFiles)
+		}
+		if err := checkChunkFilenames(filenameFormat, i+1); err != nil {
+			return err
+		}
+		result := &ChunkResult{Filename: fmt.Sprintf(filenameFormat, i), Rows: len(buffered), Rejects: rejects}
+		result.Filename, result.Err = writeChunkLabels(result.Filename, buffered, eopts)
+		results = append(results, result)
+		buffered, rejects = nil, nil
+		return nil
+	}
+	for _, o := range orders {
+		labels, err := convertOrder(o, opts)
+		if err != nil {
+			rejects = append(rejects, &RejectedOrder{Name: o.Name, Err: err, Order: o})
+			if !opts.KeepPlaceholders {
+				continue
+			}
+			labels = placeholderLabels(o)
+		}
+		if len(buffered) > 0 && len(buffered)+len(labels) > maxLabels {
+			if err := flush(); err != nil {
+				return results, err
+			}
+			if results[len(results)-1].Err != nil {
+				return results, nil
+			}
+		}
+		buffered = append(buffered, labels...)
+	}
+	if len(buffered) > 0 {
+		if err := flush(); err != nil {
+			return results, err
+		}
+	} else if len(rejects) > 0 {
+		// 最後のファイルの後にスキップした注文は、ファイルのない結果で返す
+		results = append(results, &ChunkResult{Filename: fmt.Sprintf(filenameFormat, len(results)), Rejects: rejects})
+	}
+	return results, nil
+}
+
+// removeChunkFiles 書き込んだチャンクのファイルを削除する
+func removeChunkFiles(results []*ChunkResult) {
+	for _, result := range results {
+		if result.Rows > 0 && result.Err == nil {
+			os.Remove(result.Filename)
+		}
+	}
+}
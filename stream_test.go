@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// streamTestOrders 送り状にできるn件の注文
+func streamTestOrders(n int) []*ShopifyOrder {
+	orders := make([]*ShopifyOrder, n)
+	for i := range orders {
+		orders[i] = &ShopifyOrder{
+			Name:             fmt.Sprintf("#%d", i+1),
+			ShippingName:     fmt.Sprintf("山田%d郎", i+1),
+			ShippingStreet:   "神南1-2-3",
+			ShippingCity:     "渋谷区",
+			ShippingZip:      "150-0041",
+			ShippingProvince: "東京都",
+		}
+	}
+	return orders
+}
+
+// TestExportChunksStreamingRemovesFilesOverMaxFiles ファイル数が上限を超える場合は、書き込み済みのファイルを残さない
+func TestExportChunksStreamingRemovesFilesOverMaxFiles(t *testing.T) {
+	dir := t.TempDir()
+	eopts, err := Clickpost.exportOptions("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := filepath.Join(dir, clickpostFilenameFormat)
+	if _, err := ExportChunksStreaming(streamTestOrders(100), format, 40, 2, ConvertOptions{}, eopts); err == nil {
+		t.Fatal("100件の注文を-max-files 2で書き込んでもエラーになりません")
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) > 0 {
+		t.Errorf("エラーで終了したのにファイルがあります: %v", files)
+	}
+}
+
+// heapInUse GCの後にまだ使われているヒープの大きさ
+func heapInUse() int64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapAlloc)
+}
+
+// BenchmarkExportChunks 分割してから書き込む場合と-streamで書き込む場合のメモリの使用量を比べる
+// B/opは割り当ての合計なので、書き込みの後も結果に残るメモリをretained-B/opとして別に出す
+func BenchmarkExportChunks(b *testing.B) {
+	eopts, err := Clickpost.exportOptions("", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	orders := streamTestOrders(4000)
+	maxFiles := len(orders)/Clickpost.MaxLabels + 1
+	b.Run("batch", func(b *testing.B) {
+		format := filepath.Join(b.TempDir(), clickpostFilenameFormat)
+		b.ReportAllocs()
+		var retained int64
+		for b.Loop() {
+			before := heapInUse()
+			results := ExportChunks(ChunkShopifyOrdersBy(ChunkModeGreedy, orders, Clickpost.MaxLabels), format, 1, ConvertOptions{}, eopts)
+			retained += heapInUse() - before
+			for _, result := range results {
+				if result.Err != nil {
+					b.Fatal(result.Err)
+				}
+			}
+		}
+		b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+	})
+	b.Run("stream", func(b *testing.B) {
+		format := filepath.Join(b.TempDir(), clickpostFilenameFormat)
+		b.ReportAllocs()
+		var retained int64
+		for b.Loop() {
+			before := heapInUse()
+			results, err := ExportChunksStreaming(orders, format, Clickpost.MaxLabels, maxFiles, ConvertOptions{}, eopts)
+			retained += heapInUse() - before
+			if err != nil {
+				b.Fatal(err)
+			}
+			runtime.KeepAlive(results)
+		}
+		b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+	})
+}
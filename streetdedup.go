@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// dedupeStreet Shipping StreetとShipping Address1の片方がもう片方に含まれる場合に、重ねずに含む側だけを返す
+// 「神南」と「神南1-2-3」のように町名がShipping Address1にも入力されていると、つなぐと「神南神南1-2-3」になり上限を超えやすい
+// 比較では全角・半角、空白、ハイフンの違いを無視する。重複がない場合はつないだ値とfalseを返す
+func dedupeStreet(street, address1 string) (string, bool) {
+	st, a1 := normalizeAddressKey(street), normalizeAddressKey(address1)
+	switch {
+	case st == "" || a1 == "":
+	case st == a1:
+		return street, true
+	// 町名の後ろの番地だけがShipping Address1にある場合（「神南1-2-3」と「1-2-3」）。1文字の番地は偶然の一致が多いので除く
+	case strings.HasSuffix(st, a1) && utf8.RuneCountInString(a1) >= 2:
+		return street, true
+	case strings.Contains(a1, st) && utf8.RuneCountInString(st) >= 2:
+		return address1, true
+	}
+	return street + address1, false
+}
+
+// joinStreet 送り状の住所2行目の町名と番地をつなぐ。DedupeStreetが有効な場合は重複を除く
+func (o ConvertOptions) joinStreet(street, address1 string) (string, bool) {
+	if !o.DedupeStreet {
+		return street + address1, false
+	}
+	return dedupeStreet(street, address1)
+}
+
+// FindStreetDuplicates Shipping StreetとShipping Address1の重複を除いた注文を返す。DedupeStreetが無効な場合は空
+// -split-buildingの場合は、送り状と同じくShipping Address1の番地までと比べる
+func FindStreetDuplicates(orders []*ShopifyOrder, opts ConvertOptions) []*ShopifyOrder {
+	if !opts.DedupeStreet {
+		return nil
+	}
+	var found []*ShopifyOrder
+	for _, o := range orders {
+		address1 := o.ShippingAddress1
+		if banchi, building := SplitBuildingName(address1); opts.SplitBuilding && building != "" {
+			address1 = banchi
+		}
+		if _, ok := dedupeStreet(o.ShippingStreet, address1); ok {
+			found = append(found, o)
+		}
+	}
+	return found
+}
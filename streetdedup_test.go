@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+// TestDedupeStreet 町名と番地の片方がもう片方に含まれる場合だけ重ねずにつなぎ、重複を除いたことを返す
+func TestDedupeStreet(t *testing.T) {
+	tests := []struct {
+		name        string
+		street      string
+		address1    string
+		want        string
+		wantDeduped bool
+	}{
+		{name: "重複なし", street: "神南", address1: "1-2-3", want: "神南1-2-3"},
+		{name: "町名がShipping Address1にもある", street: "神南", address1: "神南1-2-3", want: "神南1-2-3", wantDeduped: true},
+		{name: "番地がShipping Streetにもある", street: "神南1-2-3", address1: "1-2-3", want: "神南1-2-3", wantDeduped: true},
+		{name: "同じ値", street: "神南1-2-3", address1: "神南1-2-3", want: "神南1-2-3", wantDeduped: true},
+		{name: "全角・空白・ハイフンの違い", street: "神南 １－２－３", address1: "神南1-2-3", want: "神南 １－２－３", wantDeduped: true},
+		{name: "1文字の番地は偶然の一致とみなす", street: "神南1", address1: "1", want: "神南11"},
+		{name: "空欄", street: "", address1: "神南1-2-3", want: "神南1-2-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, deduped := dedupeStreet(tt.street, tt.address1)
+			if got != tt.want || deduped != tt.wantDeduped {
+				t.Errorf("dedupeStreet(%q, %q) = %q %t、%q %tを期待", tt.street, tt.address1, got, deduped, tt.want, tt.wantDeduped)
+			}
+		})
+	}
+}
+
+// TestDedupeStreetLabel -dedupe-streetの場合だけ送り状の住所2行目の重複を除き、重複を除いた注文を知らせる
+func TestDedupeStreetLabel(t *testing.T) {
+	overlapping := &ShopifyOrder{Name: "#1", ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "神南", ShippingAddress1: "神南1-2-3"}
+	disjoint := &ShopifyOrder{Name: "#2", ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "宇田川町", ShippingAddress1: "1-1"}
+	opts := DefaultConvertOptions()
+	if got := overlapping.ToClickpostShippingLabel(opts).ShippingAddress2; got != "神南神南1-2-3" {
+		t.Errorf("-dedupe-streetなしの住所2行目 = %q、神南神南1-2-3を期待", got)
+	}
+	if found := FindStreetDuplicates([]*ShopifyOrder{overlapping, disjoint}, opts); found != nil {
+		t.Errorf("-dedupe-streetなしの重複を除いた注文 = %v、nilを期待", found)
+	}
+
+	opts.DedupeStreet = true
+	if got := overlapping.ToClickpostShippingLabel(opts).ShippingAddress2; got != "神南1-2-3" {
+		t.Errorf("住所2行目 = %q、神南1-2-3を期待", got)
+	}
+	if got := disjoint.ToClickpostShippingLabel(opts).ShippingAddress2; got != "宇田川町1-1" {
+		t.Errorf("重複のない住所2行目 = %q、宇田川町1-1を期待", got)
+	}
+	found := FindStreetDuplicates([]*ShopifyOrder{overlapping, disjoint}, opts)
+	if len(found) != 1 || found[0] != overlapping {
+		t.Errorf("重複を除いた注文 = %v、#1だけを期待", found)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// ErrUnencodableChars 送り状に出力の文字コードで表せない文字がある。-strict-encodingの場合は何も書き込まずに終了する
+var ErrUnencodableChars = errors.New("出力の文字コードで表せない文字があります")
+
+// UnencodableChar 送り状の項目にある、出力の文字コードで表せない文字
+type UnencodableChar struct {
+	OrderName string // 注文番号
+	Column    string // 列名
+	Char      rune   // 表せない文字
+}
+
+func (u UnencodableChar) String() string {
+	return fmt.Sprintf("注文番号:%s %s: 「%c」（U+%04X）", u.OrderName, u.Column, u.Char, u.Char)
+}
+
+// FindUnencodableChars 送り状に書き込む列のうち、eoptsの文字コードで表せない文字をすべて返す
+// UTF-8はすべての文字を表せるので、Shift-JISの場合だけ確かめる
+func FindUnencodableChars(labels []*ClickpostShippingLabel, eopts ExportOptions) ([]UnencodableChar, error) {
+	if eopts.Encoding != "" && eopts.Encoding != EncodingShiftJIS {
+		return nil, nil
+	}
+	t := eopts.Template
+	if t == nil {
+		var err error
+		if t, err = ClickpostTemplate(nil); err != nil {
+			return nil, err
+		}
+	}
+	encoder := japanese.ShiftJIS.NewEncoder()
+	var found []UnencodableChar
+	for i, record := range t.Records(labels)[1:] {
+		for j, value := range record {
+			for _, r := range value {
+				if _, err := encoder.String(string(r)); err != nil {
+					found = append(found, UnencodableChar{OrderName: labels[i].OrderName, Column: t.Columns[j].Header, Char: r})
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+// checkEncodable 注文を送り状に変換し、出力の文字コードで表せない文字があればすべて表示してErrUnencodableCharsを返す
+// 書き込みの途中で止まって一部のファイルだけが残らないよう、ファイルを書き込む前に確かめる
+func checkEncodable(orders []*ShopifyOrder, opts ConvertOptions, eopts ExportOptions) error {
+	labels, _ := BuildClickpostShippingLabels(orders, opts)
+	found, err := FindUnencodableChars(labels, eopts)
+	if err != nil {
+		return err
+	}
+	for _, u := range found {
+		warnf("%s\n", u)
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("%w: %d文字", ErrUnencodableChars, len(found))
+	}
+	return nil
+}
+
+// utf8FallbackFilename -retry-encodingでUTF-8にしたファイルの名前。拡張子の前に「-utf8」を付けてShift-JISのファイルと区別する
+func utf8FallbackFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "-utf8" + ext
+}
+
+// writeChunkLabels チャンクの送り状をファイルに書き込み、書き込んだファイル名を返す
+// RetryEncodingの場合は、Shift-JISで表せない文字がある送り状を、そのファイルだけUTF-8で別の名前に書き込む
+func writeChunkLabels(filename string, labels []*ClickpostShippingLabel, eopts ExportOptions) (string, error) {
+	if eopts.RetryEncoding {
+		found, err := FindUnencodableChars(labels, eopts)
+		if err != nil {
+			return filename, err
+		}
+		if len(found) > 0 {
+			fallback := utf8FallbackFilename(filename)
+			warnf("注意: Shift-JISで表せない文字（%s）があるため、%sの代わりにUTF-8で%sを書き込みます。アップロード先がUTF-8を受け付けるか確認してください\n", found[0], filepath.Base(filename), fallback)
+			eopts.Encoding = EncodingUTF8
+			filename = fallback
+		}
+	}
+	return filename, chunkWritePacer.pace(eopts.WriteDelay, func() error { return writeLabels(filename, labels, eopts) })
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// modeFlags 変換以外の処理に切り替えるフラグ。サブコマンドでは名前で処理を選ぶので受け付けない
+var modeFlags = map[string]bool{
+	"verify": true, "lint": true, "sample": true, "preview-json": true, "count": true,
+	"validate": true, "explain": true, "debug-bytes": true, "reprocess-file": true,
+	"show-order": true, "diff": true, "describe-mapping": true,
+}
+
+// outputFlags 送り状のファイルの書き込み方を決めるフラグ。ファイルを書き込まないサブコマンドでは受け付けない
+var outputFlags = map[string]bool{
+	"single-file": true, "encoding": true, "line-ending": true, "zip": true, "manifest": true, "manifest-order-date": true, "timezone": true, "fix-list": true, "split-by": true, "sheet-layout": true, "sheet-fill": true, "write-delay": true,
+	"append": true, "chunk-range": true, "chunk-mode": true, "parallel": true, "max-files": true,
+	"stage-dir": true, "filename-stamp": true, "carrier-template": true, "rename-columns": true, "column-headers": true,
+	"order-column": true, "column-order": true, "barcode-column": true, "report": true, "zip-list": true,
+	"checksums": true, "warn-chunk-count": true, "watch": true, "watch-out": true, "watch-interval": true,
+	"stream": true, "strict-encoding": true, "retry-encoding": true,
+}
+
+// consoleFlags メッセージの出力を決めるフラグ。すべてのサブコマンドで受け付ける
+var consoleFlags = map[string]bool{"locale": true, "quiet": true, "verbose": true}
+
+// carrierFlags 配送業者と検証ルールを決めるフラグ。出力済みのファイルを確かめるサブコマンドでも、変換と同じルールで確かめるために受け付ける
+var carrierFlags = map[string]bool{"carriers": true, "carrier": true, "max-len": true, "lenient-length": true}
+
+// subcommand サブコマンドの定義
+type subcommand struct {
+	usage string            // ヘルプに表示する説明
+	args  string            // ヘルプに表示するフラグ以外の引数
+	flag  func(string) bool // flag.CommandLineのフラグのうち、受け付けるもの
+	// apply 引数の解析後に、対応するフラグを設定してrunの処理を選ぶ
+	apply func(fs *flag.FlagSet) error
+}
+
+// subcommands サブコマンドの一覧。サブコマンドを指定しない場合はconvertと同じで、すべてのフラグを受け付ける
+var subcommands = map[string]subcommand{
+	"convert": {
+		usage: "Shopifyの注文データを送り状発行用CSVに変換する",
+		flag:  func(name string) bool { return !modeFlags[name] },
+		apply: noArgs,
+	},
+	"validate": {
+		usage: "ファイルを書き込まず、送り状にできない注文と理由を表示する",
+		flag:  func(name string) bool { return !modeFlags[name] && !outputFlags[name] },
+		apply: setMode("validate"),
+	},
+	"preview": {
+		usage: "ファイルを書き込まず、送り状と検証結果をJSONで表示する",
+		flag:  func(name string) bool { return !modeFlags[name] && !outputFlags[name] },
+		apply: setMode("preview-json"),
+	},
+	"count": {
+		usage: "ファイルを書き込まず、作られる送り状の枚数を表示する",
+		flag:  func(name string) bool { return !modeFlags[name] && !outputFlags[name] },
+		apply: setMode("count"),
+	},
+	"show": {
+		usage: "ファイルを書き込まず、1つの注文の送り状と検証結果を表示する",
+		args:  " 注文番号",
+		flag:  func(name string) bool { return !modeFlags[name] && !outputFlags[name] },
+		apply: func(fs *flag.FlagSet) error {
+			if fs.NArg() != 1 {
+				return fmt.Errorf("showには注文番号を1つ指定してください")
+			}
+			return flag.Set("show-order", fs.Arg(0))
+		},
+	},
+	"diff": {
+		usage: "ファイルを書き込まず、前回出力した送り状と今の設定で作る送り状の違いを表示する",
+		args:  " ファイル...",
+		flag:  func(name string) bool { return !modeFlags[name] },
+		apply: func(fs *flag.FlagSet) error {
+			if fs.NArg() == 0 {
+				return fmt.Errorf("diffには前回出力した送り状のCSVを1つ以上指定してください")
+			}
+			return flag.Set("diff", strings.Join(fs.Args(), ","))
+		},
+	},
+	"verify": {
+		usage: "出力済みの送り状発行用CSVをアップロードできるか検証する",
+		args:  " ファイル",
+		flag:  func(name string) bool { return consoleFlags[name] || carrierFlags[name] },
+		apply: func(fs *flag.FlagSet) error {
+			if fs.NArg() != 1 {
+				return fmt.Errorf("verifyには送り状発行用CSVを1つ指定してください")
+			}
+			return flag.Set("verify", fs.Arg(0))
+		},
+	},
+	"lint": {
+		usage: "出力済みの送り状発行用CSVの各行が検証ルールを満たすか確かめる",
+		args:  " ファイル",
+		flag:  func(name string) bool { return consoleFlags[name] || carrierFlags[name] || name == "mask" },
+		apply: func(fs *flag.FlagSet) error {
+			if fs.NArg() != 1 {
+				return fmt.Errorf("lintには送り状発行用CSVを1つ指定してください")
+			}
+			return flag.Set("lint", fs.Arg(0))
+		},
+	},
+	"sample": {
+		usage: "入力用CSVのテンプレートを作成する",
+		flag:  func(name string) bool { return consoleFlags[name] },
+		apply: setMode("sample"),
+	},
+}
+
+// noArgs フラグ以外の引数を受け付けない
+func noArgs(fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%sに余分な引数があります: %s", fs.Name(), fs.Arg(0))
+	}
+	return nil
+}
+
+// setMode フラグ以外の引数がないことを確かめ、処理を選ぶ真偽値のフラグを設定する
+func setMode(name string) func(fs *flag.FlagSet) error {
+	return func(fs *flag.FlagSet) error {
+		if err := noArgs(fs); err != nil {
+			return err
+		}
+		return flag.Set(name, "true")
+	}
+}
+
+// parseArgs コマンドライン引数を解析する
+// 先頭の引数がサブコマンドの場合は、そのサブコマンドのフラグだけを受け付けるFlagSetで解析する
+// フラグは値をflag.CommandLineと共有するので、runはサブコマンドの有無によらず同じように動く
+func parseArgs(args []string) error {
+	// flag.ExitOnErrorでは終了コード2になり、スキップした注文がある場合と区別できないので、エラーを返して終了コードを決める
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.Usage = usage
+	if len(args) == 0 || subcommands[args[0]].flag == nil {
+		if err := flag.CommandLine.Parse(args); err != nil {
+			return err
+		}
+		if flag.NArg() > 0 {
+			return fmt.Errorf("不明なサブコマンドか余分な引数です: %s", flag.Arg(0))
+		}
+		return nil
+	}
+	cmd := subcommands[args[0]]
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		if cmd.flag(f.Name) {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "使い方: %s %s [フラグ]%s\n%s\n\n", os.Args[0], args[0], cmd.args, cmd.usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	return cmd.apply(fs)
+}
+
+// usage サブコマンドを指定しない場合のヘルプ
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "使い方: %s [サブコマンド] [フラグ]\n\nサブコマンド（指定しない場合はconvert）:\n", os.Args[0])
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-10s %s\n", name, subcommands[name].usage)
+	}
+	fmt.Fprintf(out, "\nフラグ:\n")
+	flag.PrintDefaults()
+}
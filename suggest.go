@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// SuggestFix スキップした注文の直し方を、検証エラーのコードと問題の項目の実際の値から提案する
+// Shopifyのデータを直すストアの担当者に、そのまま伝えられる文にする。提案がない場合は空を返す
+func SuggestFix(o *ShopifyOrder, opts ConvertOptions, err error) string {
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		return ""
+	}
+	switch ve.Code {
+	case "zip_required":
+		return "Shipping Zipに郵便番号を入力してください"
+	case "name_required":
+		return "Shipping Nameに氏名を入力するか、会社宛ての場合はShipping Companyに会社名を入力してください"
+	case "address1_required":
+		return "Shipping ProvinceとShipping Cityに都道府県と市区町村を入力してください"
+	case "address2_required":
+		return "Shipping StreetかShipping Address1に町名・番地を入力してください"
+	case "invalid_box_count", "too_many_boxes":
+		return fmt.Sprintf("Box Countに1〜%dの整数を入力するか、空欄にしてください", maxBoxCount)
+	case "address_total_too_long":
+		total := addressTotalLength(o.ToClickpostShippingLabel(opts))
+		return fmt.Sprintf("住所が4行で合計%d文字です（上限%d文字）。同じ町名や番地が複数の行に入力されていないか確認してください", total, opts.MaxAddressTotal)
+	case "special_address":
+		return "お客様に配達できる住所を確認するか、-special-address separateで別のファイルに書き込んでください"
+	case "name_no_letters":
+		return "Shipping Nameに電話番号や注文番号が入っていないか確認し、お客様の氏名を入力してください"
+	}
+	rule, ok := opts.carrier().ruleByCode(ve.Code)
+	if !ok {
+		return ""
+	}
+	value := reflect.ValueOf(o.ToClickpostShippingLabel(opts)).Elem().FieldByName(rule.Field).String()
+	n := utf8.RuneCountInString(value)
+	switch strings.TrimPrefix(ve.Code, rule.Code) {
+	case "_too_long":
+		return fmt.Sprintf("%sが%d文字です（上限%d文字）。%s", rule.Label, n, rule.MaxLen, tooLongHints[rule.Code])
+	case "_control_char":
+		return fmt.Sprintf("%sの改行やタブを取り除くか、-control-chars sanitizeを指定してください", rule.Label)
+	}
+	return ""
+}
+
+// tooLongHints 文字数超過の項目ごとの直し方
+var tooLongHints = map[string]string{
+	"name":     "肩書きやミドルネームを省いてください",
+	"address1": "市区町村を短縮するか2行目へ分割してください",
+	"address2": "建物名をShipping Address2に移すか、-split-buildingを指定してください",
+	"address3": "建物名・部屋番号を短縮してください",
+	"address4": "建物名・部屋番号を短縮してください",
+	"contents": "-contentsの品目を減らすか、2箱以上の注文では-split-contentsを指定してください",
+}
+
+// skipSuggester -explain-skipsの場合に、スキップした注文の直し方を提案する関数を返す。指定しない場合はnil
+func skipSuggester(opts ConvertOptions) func(*RejectedOrder) string {
+	if !*explainSkips {
+		return nil
+	}
+	return func(r *RejectedOrder) string { return SuggestFix(r.Order, opts, r.Err) }
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// CarrierTemplate 送り状のCSVの列の定義
+// 配送業者の管理画面で列が変わった場合に、再ビルドせずに設定ファイルで出力の形式を合わせる
+type CarrierTemplate struct {
+	Columns []TemplateColumn `json:"columns"` // 出力する列。この順に書き込む
+}
+
+// TemplateColumn 送り状のCSVの1列
+type TemplateColumn struct {
+	Header string `json:"header"` // ヘッダー行に書き込む列名
+	Field  string `json:"field"`  // 値を取るClickpostShippingLabelのフィールド名。例: ShippingZip
+	Value  string `json:"value"`  // Fieldが空の場合に書き込む固定値
+}
+
+// LoadCarrierTemplate JSONの設定ファイルから送り状のCSVの列の定義を読み込む
+// {"columns": [{"header": "郵便番号", "field": "ShippingZip"}, {"header": "品名", "value": "サプリメント"}]}
+func LoadCarrierTemplate(filename string) (*CarrierTemplate, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var t CarrierTemplate
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("%sを読み込めません: %w", filename, err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &t, nil
+}
+
+// validate 列の定義が送り状のフィールドに対応しているか検証する
+func (t *CarrierTemplate) validate() error {
+	if len(t.Columns) == 0 {
+		return errors.New("列が定義されていません")
+	}
+	labelType := reflect.TypeOf(ClickpostShippingLabel{})
+	for i, c := range t.Columns {
+		if c.Header == "" {
+			return fmt.Errorf("%d列目のheaderが空です", i+1)
+		}
+		if c.Field == "" {
+			continue
+		}
+		if f, ok := labelType.FieldByName(c.Field); !ok || f.Type.Kind() != reflect.String {
+			return fmt.Errorf("%sの列のfield「%s」は送り状のフィールドにありません", c.Header, c.Field)
+		}
+	}
+	return nil
+}
+
+// Records 送り状をヘッダー行付きのCSVの行にする
+func (t *CarrierTemplate) Records(labels []*ClickpostShippingLabel) [][]string {
+	header := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		header = append(header, c.Header)
+	}
+	records := [][]string{header}
+	for _, l := range labels {
+		v := reflect.ValueOf(l).Elem()
+		record := make([]string, 0, len(t.Columns))
+		for _, c := range t.Columns {
+			if c.Field == "" {
+				record = append(record, c.Value)
+				continue
+			}
+			record = append(record, v.FieldByName(c.Field).String())
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// encodeLabels 送り状をCSVとしてwに書き込む。列の定義がある場合はそれに従い、ない場合はクリックポストの列にする
+// 分割の誤りや設定の誤りでアップロードの上限を超える場合は、アップロードで弾かれる前に書き込まずにエラーにする
+func encodeLabels(w io.Writer, labels []*ClickpostShippingLabel, eopts ExportOptions) error {
+	if err := checkLabelLimit(labels, eopts); err != nil {
+		return err
+	}
+	labels = eopts.SheetLayout.Arrange(labels)
+	if eopts.Template == nil {
+		return encodeCSV(w, &labels, eopts)
+	}
+	return encodeRecords(w, eopts.Template.Records(labels), eopts)
+}
+
+// encodeRecords ヘッダー行を含む行をCSVとしてwに書き込む
+func encodeRecords(w io.Writer, records [][]string, eopts ExportOptions) error {
+	encoder, err := eopts.encodingWriter(w)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(encoder)
+	writer.UseCRLF = eopts.LineEnding != LineEndingLF
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// ErrTooManyLabels 1ファイルの送り状が配送業者の上限を超えている
+var ErrTooManyLabels = errors.New("1ファイルの送り状が上限を超えるため書き込みません")
+
+// checkLabelLimit 1ファイルの送り状が配送業者の上限以内か確かめる
+func checkLabelLimit(labels []*ClickpostShippingLabel, eopts ExportOptions) error {
+	if n := eopts.carrier().MaxLabels; len(labels) > n {
+		return fmt.Errorf("%w: %d件（上限%d件）", ErrTooManyLabels, len(labels), n)
+	}
+	return nil
+}
+
+// writeLabels 送り状をCSVとしてファイルに書き込む。上限を超える場合はファイルを作らない
+func writeLabels(filename string, labels []*ClickpostShippingLabel, eopts ExportOptions) error {
+	if err := checkLabelLimit(labels, eopts); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, func(w io.Writer) error { return encodeLabels(w, labels, eopts) })
+}
+
+// ClickpostTemplate クリックポストの列の定義。ClickpostShippingLabelのcsvタグから作る
+// renamesに現在の列名から新しい列名への対応を渡すと、その列のヘッダーだけを変える
+// クリックポストのテンプレートの改訂で列名が変わった場合に、再ビルドせずに合わせるために使う
+func ClickpostTemplate(renames map[string]string) (*CarrierTemplate, error) {
+	t := &CarrierTemplate{}
+	labelType := reflect.TypeOf(ClickpostShippingLabel{})
+	used := map[string]bool{}
+	for i := 0; i < labelType.NumField(); i++ {
+		f := labelType.Field(i)
+		header := f.Tag.Get("csv")
+		if header == "" || header == "-" {
+			continue
+		}
+		if renamed, ok := renames[header]; ok {
+			header, used[header] = renamed, true
+		}
+		t.Columns = append(t.Columns, TemplateColumn{Header: header, Field: f.Name})
+	}
+	for from := range renames {
+		if !used[from] {
+			return nil, fmt.Errorf("クリックポストの送り状に「%s」の列はありません", from)
+		}
+	}
+	return t, t.validate()
+}
+
+// ReorderColumns 列をfieldsのフィールド名の順に並べ替える
+// クリックポストのテンプレートの改訂で列の順番が変わった場合に、再ビルドせずに合わせるために使う
+// fieldsには列の定義のフィールドを過不足なく1回ずつ指定する
+func (t *CarrierTemplate) ReorderColumns(fields []string) error {
+	byField := map[string]TemplateColumn{}
+	var known []string
+	for _, c := range t.Columns {
+		byField[c.Field] = c
+		known = append(known, c.Field)
+	}
+	var columns []TemplateColumn
+	for _, field := range fields {
+		c, ok := byField[field]
+		if !ok {
+			return fmt.Errorf("列の順番の「%s」は送り状の列にないか、2回以上指定されています。指定できるフィールド: %s", field, strings.Join(known, ","))
+		}
+		delete(byField, field)
+		columns = append(columns, c)
+	}
+	if len(byField) > 0 {
+		var missing []string
+		for _, field := range known {
+			if _, ok := byField[field]; ok {
+				missing = append(missing, field)
+			}
+		}
+		return fmt.Errorf("列の順番に指定されていないフィールドがあります: %s", strings.Join(missing, ","))
+	}
+	t.Columns = columns
+	return nil
+}
+
+// ParseColumnOrder 「ShippingZip,ShippingName,...」の形式の列の順番を読む
+func ParseColumnOrder(s string) []string {
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ParseColumnRenames 「お届け先敬称=敬称,内容品=品名」の形式の列名の変更を読む
+func ParseColumnRenames(s string) (map[string]string, error) {
+	renames := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(entry, "=")
+		if from, to = strings.TrimSpace(from), strings.TrimSpace(to); !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("列名の変更は現在の列名=新しい列名の形式で指定してください: %s", entry)
+		}
+		renames[from] = to
+	}
+	return renames, nil
+}
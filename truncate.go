@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// defaultNameTruncationMarker 氏名を切り詰めたことを示す目印のデフォルト
+const defaultNameTruncationMarker = "…"
+
+// nameTruncationMarker 配送業者の氏名の切り詰めの目印。定義がなければ「…」
+func (c *Carrier) nameTruncationMarker() string {
+	if c.NameTruncationMarker == "" {
+		return defaultNameTruncationMarker
+	}
+	return c.NameTruncationMarker
+}
+
+// nameTruncationMarker 氏名の切り詰めに使う目印。-name-truncation-markerの指定がなければ配送業者の目印
+func (o ConvertOptions) nameTruncationMarker() string {
+	if o.NameTruncationMarker != "" {
+		return o.NameTruncationMarker
+	}
+	return o.carrier().nameTruncationMarker()
+}
+
+// validateTruncateName 目印と接頭辞・接尾辞だけで氏名の上限に達しないか確かめる
+func (o ConvertOptions) validateTruncateName() error {
+	if !o.TruncateName {
+		return nil
+	}
+	n := o.carrier().maxLen("ShippingName")
+	if n == 0 {
+		return fmt.Errorf("-truncate-nameを指定する場合は%sのお届け先氏名に文字数の上限を定義してください", o.carrier().Name)
+	}
+	if utf8.RuneCountInString(o.NamePrefix+o.NameSuffix+o.nameTruncationMarker()) >= n {
+		return fmt.Errorf("-truncate-nameの目印と氏名の接頭辞・接尾辞は合わせて全角%d文字未満にしてください", n)
+	}
+	return nil
+}
+
+// truncateName 接頭辞と接尾辞を付けると氏名の上限を超える場合に、氏名の末尾を切り詰めて目印を付ける
+// 目印と接頭辞・接尾辞も含めて上限に収まるよう、それらの文字数を差し引いた長さで切る。切り詰めた場合はtrueを返す
+func (o ConvertOptions) truncateName(name string) (string, bool) {
+	n := o.carrier().maxLen("ShippingName")
+	if n == 0 || utf8.RuneCountInString(o.decorateName(name)) <= n {
+		return name, false
+	}
+	marker := o.nameTruncationMarker()
+	budget := n - utf8.RuneCountInString(o.NamePrefix+o.NameSuffix+marker)
+	return string([]rune(name)[:budget]) + marker, true
+}
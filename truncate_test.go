@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestTruncateName 上限を超える氏名は、目印と接頭辞・接尾辞を含めて上限ちょうどに収まるよう末尾を切り詰める
+func TestTruncateName(t *testing.T) {
+	long := strings.Repeat("山", 25)
+	tests := []struct {
+		name   string
+		input  string
+		prefix string
+		suffix string
+		marker string
+		want   string
+	}{
+		{name: "上限以内", input: "山田太郎", want: "山田太郎"},
+		{name: "上限ちょうど", input: strings.Repeat("山", 20), want: strings.Repeat("山", 20)},
+		{name: "デフォルトの目印", input: long, want: strings.Repeat("山", 19) + "…"},
+		{name: "半角の氏名", input: strings.Repeat("a", 25), want: strings.Repeat("a", 19) + "…"},
+		{name: "指定した目印", input: long, marker: "...", want: strings.Repeat("山", 17) + "..."},
+		{name: "接頭辞と接尾辞", input: long, prefix: "【至急】", suffix: "[G]", want: "【至急】" + strings.Repeat("山", 12) + "…[G]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultConvertOptions()
+			opts.TruncateName, opts.NameTruncationMarker = true, tt.marker
+			opts.NamePrefix, opts.NameSuffix = tt.prefix, tt.suffix
+			if err := opts.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			o := ShopifyOrder{ShippingName: tt.input, ShippingZip: "150-0041", ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "神南1-2-3"}
+			l := o.ToClickpostShippingLabel(opts)
+			if l.ShippingName != tt.want {
+				t.Errorf("氏名 = %q、%qを期待", l.ShippingName, tt.want)
+			}
+			if n := utf8.RuneCountInString(l.ShippingName); n > 20 {
+				t.Errorf("氏名 = %d文字、20文字以内を期待", n)
+			}
+			if err := l.Validate(); err != nil {
+				t.Errorf("Validate() = %v、nilを期待", err)
+			}
+		})
+	}
+}
+
+// TestTruncateNameCarrier 配送業者の設定ファイルの氏名の上限と目印で切り詰め、-name-truncation-markerの指定を優先する
+func TestTruncateNameCarrier(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "carriers.json")
+	const carriers = `{"carriers": {"yupack": {"max_labels": 30, "name_truncation_marker": "＊", "fields": [
+		{"field": "ShippingName", "code": "name", "label": "お届け先氏名", "max_len": 10}
+	]}}}`
+	if err := os.WriteFile(filename, []byte(carriers), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := LoadCarriers(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultConvertOptions()
+	opts.Carrier, opts.TruncateName = configs["yupack"].Carrier, true
+	name := "株式会社サンプル商事渋谷支店"
+	if got, ok := opts.truncateName(name); !ok || got != "株式会社サンプル商＊" {
+		t.Errorf("truncateName = %q %t、「株式会社サンプル商＊」を期待", got, ok)
+	}
+	opts.NameTruncationMarker = "..."
+	if got, _ := opts.truncateName(name); got != "株式会社サンプ..." {
+		t.Errorf("truncateName = %q、「株式会社サンプ...」を期待", got)
+	}
+
+	// 目印と接頭辞だけで上限に達する指定は、変換を始める前にエラーにする
+	opts.NamePrefix = "【至急】ギフト"
+	if err := opts.validateTruncateName(); err == nil {
+		t.Error("目印と接頭辞で上限に達する指定がエラーになりません")
+	}
+}
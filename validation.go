@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidationError 送り状の検証エラー
+// Codeは言語に依存しない安定した識別子で、呼び出し側で独自のメッセージに対応付けられる
+type ValidationError struct {
+	Code    string // エラーコード
+	Message string // CLI向けの日本語のメッセージ
+	// Value 文字数の上限を超えた項目の値。個人情報を含むのでError()には含めず、表示するときにvalueDetailで添える
+	Value string
+	// Length Valueの文字数。上限と同じく全角・半角を区別せず1文字と数える
+	Length int
+	// MaxLen 文字数の超過の場合の、検証に使った上限
+	MaxLen int
+}
+
+func (e *ValidationError) Error() string {
+	if e.Value == "" {
+		return e.Message
+	}
+	return e.Message + fmt.Sprintf("（現在%d文字）", e.Length)
+}
+
+// valueDetail 検証エラーに添える値と文字数の日本語の表示。-maskの場合は値の先頭の2文字以外を伏せる
+func (e *ValidationError) valueDetail() string {
+	if e.Value == "" {
+		return ""
+	}
+	return fmt.Sprintf("（現在%d文字: '%s'）", e.Length, e.excerpt())
+}
+
+// excerpt 表示する値の先頭。-maskの場合は伏せてから切り出す
+func (e *ValidationError) excerpt() string {
+	if maskOutput {
+		return excerpt(maskRunes(e.Value, 2))
+	}
+	return excerpt(e.Value)
+}
+
+// withValue 文字数の上限を超えた値を添えた検証エラーを返す。eは変更しない
+// 操作する人が自分で文字数を数えなくても、どれだけ短くすればよいか分かるようにする
+func (e *ValidationError) withValue(value string) *ValidationError {
+	copied := *e
+	copied.Value, copied.Length = value, utf8.RuneCountInString(value)
+	return &copied
+}
+
+// excerptLen メッセージに添える値の先頭の文字数
+const excerptLen = 10
+
+// excerpt 値の先頭のexcerptLen文字。長い値は「…」で省略する
+func excerpt(s string) string {
+	r := []rune(s)
+	if len(r) <= excerptLen {
+		return s
+	}
+	return string(r[:excerptLen]) + "…"
+}
+
+// Is エラーコードが同じ検証エラーを同じエラーとみなす
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ValidationError)
+	return ok && t.Code == e.Code
+}
+
+// 送り状の検証エラー。errors.Isで判定できる
+var (
+	ErrZipRequired         = &ValidationError{Code: "zip_required", Message: "お届け先郵便番号は必須です"}
+	ErrNameRequired        = &ValidationError{Code: "name_required", Message: "お届け先氏名は必須です"}
+	ErrNameTooLong         = &ValidationError{Code: "name_too_long", Message: "お届け先氏名は全角20文字までです"}
+	ErrNameNoLetters       = &ValidationError{Code: "name_no_letters", Message: "お届け先氏名が数字や記号だけです。電話番号や注文番号が入力されていないか確認してください"}
+	ErrAddress1Required    = &ValidationError{Code: "address1_required", Message: "お届け先住所1行目は必須です"}
+	ErrAddress1TooLong     = &ValidationError{Code: "address1_too_long", Message: "お届け先住所1行目は全角20文字までです"}
+	ErrAddress2Required    = &ValidationError{Code: "address2_required", Message: "お届け先住所2行目は必須です"}
+	ErrAddress2TooLong     = &ValidationError{Code: "address2_too_long", Message: "お届け先住所2行目は全角20文字までです"}
+	ErrAddress3TooLong     = &ValidationError{Code: "address3_too_long", Message: "お届け先住所3行目は全角20文字までです"}
+	ErrAddress4TooLong     = &ValidationError{Code: "address4_too_long", Message: "お届け先住所4行目は全角20文字までです"}
+	ErrAddressTotalTooLong = &ValidationError{Code: "address_total_too_long", Message: "お届け先住所の合計の文字数が上限を超えています"}
+	ErrContentsRequired    = &ValidationError{Code: "contents_required", Message: "内容品は必須です"}
+	ErrContentsTooLong     = &ValidationError{Code: "contents_too_long", Message: "内容品は全角15文字までです"}
+	ErrInvalidBoxCount     = &ValidationError{Code: "invalid_box_count", Message: "箱数は1以上の整数で指定してください"}
+	ErrSpecialAddress      = &ValidationError{Code: "special_address", Message: "局留めや私書箱の住所は配送業者が受け付けないことがあるためスキップします"}
+	ErrTooManyBoxes        = &ValidationError{Code: "too_many_boxes", Message: "箱数の上限を超えています"}
+)
+
+// sanitizeControlChars 送り状の文字列項目に含まれる制御文字を空白に置き換え、前後の空白を取り除く
+func sanitizeControlChars(l *ClickpostShippingLabel) {
+	v := reflect.ValueOf(l).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.String || strings.IndexFunc(f.String(), unicode.IsControl) < 0 {
+			continue
+		}
+		f.SetString(strings.TrimSpace(strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return ' '
+			}
+			return r
+		}, f.String())))
+	}
+}
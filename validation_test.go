@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// validLabel 検証に通る送り状。テストごとに項目を書き換えて使う
+func validLabel() ClickpostShippingLabel {
+	return ClickpostShippingLabel{
+		ShippingZip:       "150-0041",
+		ShippingName:      "山田太郎",
+		ShippingNameTitle: "様",
+		ShippingAddress1:  "東京都渋谷区",
+		ShippingAddress2:  "神南1-2-3",
+		ShippingContents:  "サプリメント",
+	}
+}
+
+// TestValidateAddress3And4Length 住所3・4行目の文字数の上限を確かめる
+// 変換では4行目を埋めないことが多いので、送り状を直接組み立てて検証する
+func TestValidateAddress3And4Length(t *testing.T) {
+	tests := []struct {
+		name     string
+		address3 string
+		address4 string
+		want     *ValidationError // nilの場合は検証に通る
+	}{
+		{name: "3行目が上限ちょうど", address3: strings.Repeat("あ", 20)},
+		{name: "4行目が上限ちょうど", address4: strings.Repeat("1", 20)},
+		{name: "3行目が上限を超える", address3: strings.Repeat("あ", 21), want: ErrAddress3TooLong},
+		{name: "4行目が上限を超える", address4: strings.Repeat("あ", 21), want: ErrAddress4TooLong},
+		{name: "4行目が半角で上限を超える", address4: strings.Repeat("1", 21), want: ErrAddress4TooLong},
+		{name: "3行目と4行目が上限を超える", address3: strings.Repeat("あ", 21), address4: strings.Repeat("あ", 21), want: ErrAddress3TooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := validLabel()
+			l.ShippingAddress3, l.ShippingAddress4 = tt.address3, tt.address4
+			err := l.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v、nilを期待", err)
+				}
+				return
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("Validate() = %v、*ValidationErrorを期待", err)
+			}
+			if ve.Code != tt.want.Code {
+				t.Errorf("Code = %q、%qを期待", ve.Code, tt.want.Code)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateContentsRequired 内容品が空の送り状は必須のエラーにする
+func TestValidateContentsRequired(t *testing.T) {
+	l := validLabel()
+	l.ShippingContents = ""
+	if err := l.Validate(); !errors.Is(err, ErrContentsRequired) {
+		t.Errorf("Validate() = %v、%vを期待", err, ErrContentsRequired)
+	}
+	l.ShippingContents = "サプリメント"
+	if err := l.Validate(); err != nil {
+		t.Errorf("Validate() = %v、nilを期待", err)
+	}
+}
+
+// TestControlCharsInName 氏名の改行やタブは検証エラーにし、-control-chars sanitizeの場合は空白に置き換える
+func TestControlCharsInName(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		value     string
+		sanitized string
+	}{
+		{name: "改行", value: "山田\n太郎", sanitized: "山田 太郎"},
+		{name: "タブ", value: "山田\t太郎", sanitized: "山田 太郎"},
+		{name: "CR", value: "山田太郎\r", sanitized: "山田太郎"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			o := ShopifyOrder{ShippingName: tt.value, ShippingProvince: "東京都", ShippingCity: "渋谷区", ShippingStreet: "神南1-2-3", ShippingZip: "150-0041"}
+			opts := DefaultConvertOptions()
+			var ve *ValidationError
+			if err := o.ToClickpostShippingLabel(opts).Validate(); !errors.As(err, &ve) || ve.Code != "name_control_char" {
+				t.Errorf("Validate() = %v、name_control_charを期待", err)
+			}
+			opts.SanitizeControlChars = true
+			l := o.ToClickpostShippingLabel(opts)
+			if l.ShippingName != tt.sanitized {
+				t.Errorf("ShippingName = %q、%qを期待", l.ShippingName, tt.sanitized)
+			}
+			if err := l.Validate(); err != nil {
+				t.Errorf("Validate() = %v、nilを期待", err)
+			}
+		})
+	}
+}
+
+// benchmarkLabels 検証のベンチマークに使う、全角と半角の混ざった送り状
+func benchmarkLabels(n int) []*ClickpostShippingLabel {
+	labels := make([]*ClickpostShippingLabel, n)
+	for i := range labels {
+		l := validLabel()
+		l.ShippingName = fmt.Sprintf("山田%d郎 Taro", i)
+		l.ShippingAddress2 = fmt.Sprintf("神南%d-2-3", i)
+		l.ShippingAddress3 = fmt.Sprintf("渋谷マンション%d号室", i%1000)
+		labels[i] = &l
+	}
+	return labels
+}
+
+// widthLength 半角の文字を0.5文字として数えた文字数を、半角の文字数で返す
+func widthLength(s string) int {
+	n := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianNarrow, width.EastAsianHalfwidth, width.Neutral:
+			n++
+		default:
+			n += 2
+		}
+	}
+	return n
+}
+
+// BenchmarkValidateLength 文字数の検証を、今の文字数で数える方法と、半角を0.5文字と数える方法で比べる
+// 検証の処理だけを測るので、変換は測る前に済ませる
+func BenchmarkValidateLength(b *testing.B) {
+	labels := benchmarkLabels(5000)
+	b.Run("rune", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, l := range labels {
+				for _, r := range Clickpost.Fields {
+					if r.MaxLen > 0 && utf8.RuneCountInString(reflect.ValueOf(l).Elem().FieldByName(r.Field).String()) > r.MaxLen {
+						b.Fatalf("%sが上限を超えています", r.Field)
+					}
+				}
+			}
+		}
+	})
+	b.Run("width", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, l := range labels {
+				for _, r := range Clickpost.Fields {
+					if r.MaxLen > 0 && widthLength(reflect.ValueOf(l).Elem().FieldByName(r.Field).String()) > r.MaxLen*2 {
+						b.Fatalf("%sが上限を超えています", r.Field)
+					}
+				}
+			}
+		}
+	})
+	b.Run("validate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, l := range labels {
+				if err := l.Validate(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
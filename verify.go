@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// ClickpostShippingLabelHeaders クリックポストの送り状発行用CSVのヘッダー行
+func ClickpostShippingLabelHeaders() []string {
+	var headers []string
+	t := reflect.TypeOf(ClickpostShippingLabel{})
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("csv"); tag != "" && tag != "-" {
+			headers = append(headers, tag)
+		}
+	}
+	return headers
+}
+
+// ReadClickpostShippingLabels 出力済みの送り状発行用CSVをShift-JISとして読み込み、ヘッダー行と送り状を返す
+func ReadClickpostShippingLabels(filename string) (headers []string, labels []*ClickpostShippingLabel, err error) {
+	inFile, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer inFile.Close()
+	b, err := io.ReadAll(transform.NewReader(inFile, japanese.ShiftJIS.NewDecoder()))
+	if err != nil {
+		return nil, nil, err
+	}
+	headers, err = csv.NewReader(bytes.NewReader(b)).Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%sが空です", filename)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := gocsv.UnmarshalBytes(b, &labels); err != nil {
+		return nil, nil, err
+	}
+	return headers, labels, nil
+}
+
+// VerifyClickpostShippingLabels 出力済みの送り状発行用CSVが配送業者cにアップロードできるか検証し、見つかった問題をすべて返す
+// 生成後に手作業で編集されたファイルの確認に使う。件数の上限と検証ルールは、-max-lenなどで上書きした変換時と同じcの定義を使う
+func VerifyClickpostShippingLabels(filename string, c *Carrier) ([]string, error) {
+	headers, labels, err := ReadClickpostShippingLabels(filename)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	if expected := ClickpostShippingLabelHeaders(); !reflect.DeepEqual(headers, expected) {
+		problems = append(problems, fmt.Sprintf("ヘッダー行が一致しません 期待値:%s 実際:%s", strings.Join(expected, ","), strings.Join(headers, ",")))
+	}
+	if len(labels) > c.MaxLabels {
+		problems = append(problems, fmt.Sprintf("送り状は%d件までです: %d件", c.MaxLabels, len(labels)))
+	}
+	for i, label := range labels {
+		if err := c.Validate(label); err != nil {
+			// ヘッダー行の分を足して、ファイル上の行番号で表示する
+			problems = append(problems, fmt.Sprintf("%d行目: %s", i+2, localizeError(err)))
+		}
+	}
+	return problems, nil
+}
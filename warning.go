@@ -0,0 +1,155 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// FindSharedAddresses 正規化した住所が同じで氏名が異なる注文をグループにして返す
+// 世帯で別々に注文した場合などは正しい注文だが、重複注文の可能性もあるため確認用に使う
+func FindSharedAddresses(orders []*ShopifyOrder, opts ConvertOptions) [][]*ShopifyOrder {
+	var keys []string
+	groups := map[string][]*ShopifyOrder{}
+	for _, o := range orders {
+		label := o.ToClickpostShippingLabel(opts)
+		key := normalizeAddressKey(label.ShippingZip + label.ShippingAddress1 + label.ShippingAddress2 + label.ShippingAddress3 + label.ShippingAddress4)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], o)
+	}
+	var shared [][]*ShopifyOrder
+	for _, key := range keys {
+		names := map[string]bool{}
+		for _, o := range groups[key] {
+			names[normalizeAddressKey(o.ShippingName)] = true
+		}
+		if len(names) > 1 {
+			shared = append(shared, groups[key])
+		}
+	}
+	return shared
+}
+
+// formatOrderNames 注文番号と氏名を「#1001(田中太郎), #1002(田中花子)」の形式で並べる
+// maskがtrueの場合は氏名の一部を伏せる
+func formatOrderNames(orders []*ShopifyOrder, mask bool) string {
+	names := make([]string, 0, len(orders))
+	for _, o := range orders {
+		name := o.ShippingName
+		if mask {
+			name = MaskName(name)
+		}
+		names = append(names, o.Name+"("+name+")")
+	}
+	return strings.Join(names, ", ")
+}
+
+// japaneseZipPattern 日本の郵便番号（123-4567、1234567、〒123-4567）
+var japaneseZipPattern = regexp.MustCompile(`^〒?\s*\d{3}[-ー−‐]?\d{4}$`)
+
+// OverseasOrder 海外の住所の可能性がある注文と、そう判断した理由
+type OverseasOrder struct {
+	Order  *ShopifyOrder
+	Reason string
+}
+
+// FindOverseasOrders 郵便番号や都道府県が日本の形式ではない注文を返す
+// 海外の住所を日本の順に並べても正しい送り状にならないので、国内の配送業者に回す前に除外できるよう確認用に使う
+// 都道府県が空欄の注文は、郵便番号だけで判断する
+func FindOverseasOrders(orders []*ShopifyOrder) []*OverseasOrder {
+	var overseas []*OverseasOrder
+	for _, o := range orders {
+		zip := strings.TrimSpace(width.Fold.String(o.ShippingZip))
+		switch {
+		case zip != "" && !japaneseZipPattern.MatchString(zip):
+			overseas = append(overseas, &OverseasOrder{Order: o, Reason: "郵便番号が日本の7桁の形式ではありません: " + o.ShippingZip})
+		case o.ShippingProvince != "":
+			if _, ok := LookupPrefecture(o.ShippingProvince); !ok {
+				overseas = append(overseas, &OverseasOrder{Order: o, Reason: "都道府県が日本の都道府県ではありません: " + o.ShippingProvince})
+			}
+		}
+	}
+	return overseas
+}
+
+// addressTokens 氏名に含まれると住所らしいとみなす文字列
+var addressTokens = []string{"丁目", "番地", "番", "号", "区", "市", "町", "村"}
+
+// minAddressDigits 氏名に含まれると住所らしいとみなす数字の数
+const minAddressDigits = 3
+
+// maxNameLikeAddress 住所の欄の値を氏名らしいとみなす最大の文字数
+const maxNameLikeAddress = 10
+
+// SwappedOrder 氏名と住所を取り違えて入力した可能性がある注文と、そう判断した理由
+type SwappedOrder struct {
+	Order  *ShopifyOrder
+	Reason string
+}
+
+// FindSwappedFields 氏名が住所らしい注文と、住所が氏名らしい注文を返す
+// 氏名と住所を取り違えた注文は文字数の検証には通ってしまい、届かない送り状になるので確認用に使う
+// 氏名に都道府県名・丁目や番地などの文字列・3つ以上の数字がある場合は住所らしい、
+// 町名か住所1行目が10文字以下のかなだけの場合は氏名らしいとみなす。誤検知もあるので警告にとどめる
+func FindSwappedFields(orders []*ShopifyOrder) []*SwappedOrder {
+	var swapped []*SwappedOrder
+	for _, o := range orders {
+		if reason := addressLikeName(o.ShippingName); reason != "" {
+			swapped = append(swapped, &SwappedOrder{Order: o, Reason: "氏名が住所のようです。" + reason})
+			continue
+		}
+		for _, field := range []struct{ label, value string }{{"Shipping Street", o.ShippingStreet}, {"Shipping Address1", o.ShippingAddress1}} {
+			if isNameLike(field.value) {
+				swapped = append(swapped, &SwappedOrder{Order: o, Reason: field.label + "が氏名のようです: " + field.value})
+				break
+			}
+		}
+	}
+	return swapped
+}
+
+// addressLikeName 氏名が住所らしい理由。住所らしくない場合は空
+func addressLikeName(name string) string {
+	for _, p := range prefectures {
+		if strings.Contains(name, p.Name) {
+			return "都道府県名の「" + p.Name + "」があります"
+		}
+	}
+	digits := 0
+	for _, r := range name {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	if digits >= minAddressDigits {
+		return "数字が多くあります"
+	}
+	if digits > 0 {
+		// 数字の後ろの丁目や番地は、数字のない「区」「町」を含む氏名と区別できる
+		for _, token := range addressTokens {
+			if strings.Contains(name, token) {
+				return "数字と「" + token + "」があります"
+			}
+		}
+	}
+	return ""
+}
+
+// isNameLike 住所の欄の値が、短いかなだけの氏名らしい値か
+func isNameLike(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || utf8.RuneCountInString(s) > maxNameLikeAddress {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Space) && r != 'ー' && r != '・' {
+			return false
+		}
+	}
+	return true
+}
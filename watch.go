@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 変換した入力と変換できなかった入力を移す、監視するフォルダの中のフォルダ
+const (
+	watchArchiveDir = "archive"
+	watchFailedDir  = "failed"
+)
+
+// watchedFile 監視するフォルダで見つけたファイルの前回の確認でのサイズと更新日時
+type watchedFile struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// runWatch dirを監視し、置かれた注文データを1ファイルずつ変換する。終了するまで戻らない
+// 共有フォルダにエクスポートを置くだけで送り状ができるようにする
+// 書き込み中のファイルを読まないよう、1回の確認の間にサイズと更新日時が変わらなかったファイルだけを変換する
+func runWatch(dir, out string, interval time.Duration) error {
+	if len(in) > 0 || *appendFile != "" || *stageDir {
+		return errors.New("-watchは-in、-append、-stage-dirと同時に指定できません")
+	}
+	if *previewJSON || *debugBytes || *explain || *count || *validateOnly || *showOrder != "" || *reprocessFile != "" {
+		return errors.New("-watchはファイルを書き込まないモードや-reprocess-fileと同時に指定できません")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("-watch-intervalは0より長い間隔を指定してください: %s", interval)
+	}
+	if out == "" {
+		out = filepath.Join(dir, "labels")
+	}
+	for _, d := range []string{out, filepath.Join(dir, watchArchiveDir), filepath.Join(dir, watchFailedDir)} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return fmt.Errorf("フォルダを作成できません: %w", err)
+		}
+	}
+	infof("%sを監視しています。送り状は%sに書き込みます\n", dir, out)
+	seen, unmovable := map[string]watchedFile{}, map[string]watchedFile{}
+	for {
+		if err := watchOnce(dir, out, seen, unmovable); err != nil {
+			return err
+		}
+		time.Sleep(interval)
+	}
+}
+
+// watchOnce 監視するフォルダを1回確認し、書き込みの終わったファイルを変換する
+// unmovableには変換した後にarchiveやfailedに移動できなかったファイルを記録し、同じファイルを何度も変換しないよう、変わるまでは扱わない
+func watchOnce(dir, out string, seen, unmovable map[string]watchedFile) error {
+	ready, err := stableWatchFiles(dir, seen)
+	if err != nil {
+		return err
+	}
+	for _, path := range ready {
+		if state, ok := unmovable[path]; ok && state == seen[path] {
+			continue
+		}
+		delete(unmovable, path)
+		if !processWatchedFile(dir, out, path) {
+			unmovable[path] = seen[path]
+			continue
+		}
+		delete(seen, path)
+	}
+	return nil
+}
+
+// stableWatchFiles dirにある注文データのうち、前回の確認からサイズと更新日時が変わっていないファイルを名前順に返す
+// seenには今回の確認の結果を記録する。空のファイルはまだ書き込まれていないとみなす
+func stableWatchFiles(dir string, seen map[string]watchedFile) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("監視するフォルダを読み込めません: %w", err)
+	}
+	var ready []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || (ext != ".csv" && ext != ".xlsx") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			// 確認の間に移動や削除されたファイルは次の確認で扱う
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		current := watchedFile{Size: info.Size(), ModTime: info.ModTime()}
+		if previous, ok := seen[path]; ok && previous == current && current.Size > 0 {
+			ready = append(ready, path)
+		}
+		seen[path] = current
+	}
+	sort.Strings(ready)
+	return ready, nil
+}
+
+// processWatchedFile 注文データを1ファイル変換し、結果に応じてarchiveかfailedのフォルダに移す
+// 送り状は入力のファイル名のフォルダに書き込むので、同じ名前の送り状のファイルが上書きされない
+// スキップした注文がある場合も、ほかの注文はエクスポートしているのでarchiveに移す
+// 移動できなかった場合はfalseを返す
+func processWatchedFile(dir, out, path string) bool {
+	name := filepath.Base(path)
+	labels := filepath.Join(out, strings.TrimSuffix(name, filepath.Ext(name)))
+	moveTo := watchArchiveDir
+	err := os.MkdirAll(labels, 0o755)
+	if err == nil {
+		infof("%sを変換します\n", name)
+		err = convert(stringsFlag{path}, labels)
+	}
+	if err != nil && !errors.Is(err, ErrOrdersSkipped) {
+		warnf("%s: %s\n", name, localizeError(err))
+		moveTo = watchFailedDir
+	}
+	if err := os.Rename(path, filepath.Join(dir, moveTo, name)); err != nil {
+		warnf("%sを%sに移動できません。ファイルが変わるまで変換しません: %s\n", name, moveTo, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWatchOnceSkipsUnmovableFile archiveに移動できなかったファイルは、変わるまで変換し直さない
+func TestWatchOnceSkipsUnmovableFile(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "labels")
+	// archiveのフォルダを作らないので、変換した後の移動に失敗する
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "orders.csv")
+	writeOrdersCSV(t, path, 3, "")
+	logs := captureLog(t)
+
+	seen, unmovable := map[string]watchedFile{}, map[string]watchedFile{}
+	for range 4 {
+		if err := watchOnce(dir, out, seen, unmovable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(logs.String(), "orders.csvをarchiveに移動できません"); n != 1 {
+		t.Errorf("変換 = %d回、1回を期待:\n%s", n, logs)
+	}
+	if _, ok := unmovable[path]; !ok {
+		t.Error("移動できなかったファイルが記録されていません")
+	}
+
+	// ファイルが変わったら、もう一度変換する
+	writeOrdersCSV(t, path, 4, "")
+	for range 2 {
+		if err := watchOnce(dir, out, seen, unmovable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(logs.String(), "orders.csvをarchiveに移動できません"); n != 2 {
+		t.Errorf("ファイルが変わった後の変換 = 合計%d回、2回を期待:\n%s", n, logs)
+	}
+}
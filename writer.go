@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// LineEnding 出力するCSVの改行コード
+type LineEnding string
+
+const (
+	// LineEndingCRLF クリックポストが受け付ける改行コード
+	LineEndingCRLF LineEnding = "crlf"
+	// LineEndingLF 他のサービスやアーカイブ向けの改行コード
+	LineEndingLF LineEnding = "lf"
+)
+
+// ParseLineEnding 文字列から改行コードを返す
+func ParseLineEnding(s string) (LineEnding, error) {
+	switch le := LineEnding(s); le {
+	case LineEndingCRLF, LineEndingLF:
+		return le, nil
+	}
+	return "", fmt.Errorf("改行コードはcrlfかlfを指定してください: %s", s)
+}
+
+// Encoding 出力するCSVの文字コード
+type Encoding string
+
+const (
+	// EncodingShiftJIS クリックポストなど配送業者向け
+	EncodingShiftJIS Encoding = "sjis"
+	// EncodingUTF8BOM BOM付きUTF-8。Excelで開く場合向け
+	EncodingUTF8BOM Encoding = "utf8bom"
+	// EncodingUTF8 BOMなしUTF-8。Googleスプレッドシートに取り込む場合向け
+	EncodingUTF8 Encoding = "utf8"
+)
+
+// utf8BOM UTF-8のBOM
+const utf8BOM = "\xEF\xBB\xBF"
+
+// ParseEncoding 文字列から文字コードを返す
+func ParseEncoding(s string) (Encoding, error) {
+	switch enc := Encoding(s); enc {
+	case EncodingShiftJIS, EncodingUTF8BOM, EncodingUTF8:
+		return enc, nil
+	}
+	return "", fmt.Errorf("文字コードはsjis、utf8bom、utf8のいずれかを指定してください: %s", s)
+}
+
+// ExportOptions CSVの書き込みオプション。ゼロ値はクリックポスト向けのShift-JIS・CRLF
+type ExportOptions struct {
+	LineEnding LineEnding // 改行コード。空の場合はCRLF
+	Encoding   Encoding   // 文字コード。空の場合はShift-JIS
+	// Template 送り状のCSVの列の定義。nilの場合はクリックポストの列
+	Template *CarrierTemplate
+	// RetryEncoding Shift-JISで表せない文字があるチャンクのファイルを、UTF-8で別の名前に書き込む
+	RetryEncoding bool
+	// SheetLayout 送り状を台紙の面に並べたい順番。nilの場合はファイルの行を並べ替えない
+	SheetLayout *SheetLayout
+	// WriteDelay チャンクのファイルを書き終えてから次のファイルを書き始めるまでの間隔。0の場合は待たない
+	WriteDelay time.Duration
+	// Carrier 1ファイルの送り状の上限に使う配送業者の定義。nilの場合はクリックポスト
+	Carrier *Carrier
+}
+
+// carrier 1ファイルの送り状の上限に使う配送業者の定義
+func (o ExportOptions) carrier() *Carrier {
+	if o.Carrier != nil {
+		return o.Carrier
+	}
+	return Clickpost
+}
+
+// encodingWriter 文字コードに応じてwに書き込むWriterを返す。書き込み後にCloseする
+func (o ExportOptions) encodingWriter(w io.Writer) (io.WriteCloser, error) {
+	switch o.Encoding {
+	case EncodingUTF8BOM:
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+		return nopWriteCloser{w}, nil
+	case EncodingUTF8:
+		return nopWriteCloser{w}, nil
+	}
+	return transform.NewWriter(w, japanese.ShiftJIS.NewEncoder()), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// writeCSV 構造体のスライスをCSVとしてファイルに書き込む
+func writeCSV(filename string, rows interface{}, eopts ExportOptions) error {
+	return writeFileAtomic(filename, func(w io.Writer) error { return encodeCSV(w, rows, eopts) })
+}
+
+// writeFileAtomic 同じフォルダの一時ファイルにwriteで書き込んでから、filenameを置き換える
+// 文字コードの変換などで途中で失敗しても、書きかけのファイルが残らず、既存のファイルもそのまま残る
+func writeFileAtomic(filename string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".shopify-shipping-csv-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	// CreateTempは所有者だけが読めるファイルを作るので、os.Createで書いた場合と揃える
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// encodeCSV 構造体のスライスをCSVとしてwに書き込む
+// gocsvのグローバルな設定は使わず、呼び出しごとにWriterを作る
+// カンマや「"」を含む項目はcsv.Writerが文字コードの変換前に引用符で囲む。Shift-JISの2バイト目にカンマや「"」のバイトは現れないため、変換後も列はずれない
+func encodeCSV(w io.Writer, rows interface{}, eopts ExportOptions) error {
+	encoder, err := eopts.encodingWriter(w)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(encoder)
+	writer.UseCRLF = eopts.LineEnding != LineEndingLF
+	if err := gocsv.MarshalCSV(rows, gocsv.NewSafeCSVWriter(writer)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// checkWritable 出力先ディレクトリに一時ファイルを作成・削除できるか確かめる
+// 変換処理を終えてから書き込みに失敗しないよう、処理の最初に確認する
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".shopify-shipping-csv-*")
+	if err != nil {
+		return fmt.Errorf("出力先ディレクトリに書き込めません: %w", err)
+	}
+	f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return fmt.Errorf("出力先ディレクトリに書き込めません: %w", err)
+	}
+	return nil
+}
+
+// outputPath 出力ファイル名を出力先のフォルダ（-stage-dirの一時ディレクトリや-watchの出力先）の下に置き換える。dirが空の場合はそのまま返す
+func outputPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return filepath.Join(dir, filepath.Base(name))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// TestWriteLabelsKeepsFileOnEncodeError Shift-JISで表せない文字で書き込みに失敗しても、既存のファイルをそのまま残す
+func TestWriteLabelsKeepsFileOnEncodeError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "labels.csv")
+	l := validLabel()
+	if err := writeLabels(filename, []*ClickpostShippingLabel{&l}, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unencodable := validLabel()
+	unencodable.ShippingName = "𠮷田太郎"
+	if err := writeLabels(filename, []*ClickpostShippingLabel{&l, &unencodable}, ExportOptions{}); err == nil {
+		t.Fatal("Shift-JISで表せない文字の書き込みがエラーになりません")
+	}
+	after, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("書き込みに失敗したファイル = %q、元の%qを期待", after, before)
+	}
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(filename), "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("フォルダのファイル = %v、一時ファイルが残っています", files)
+	}
+}
+
+// TestWriteLabelsLineEnding 選んだ改行コードで書き込む。指定しない場合はクリックポスト向けのCRLF
+func TestWriteLabelsLineEnding(t *testing.T) {
+	tests := []struct {
+		name       string
+		lineEnding LineEnding
+		want       string
+	}{
+		{name: "指定なし", want: "\r\n"},
+		{name: "crlf", lineEnding: LineEndingCRLF, want: "\r\n"},
+		{name: "lf", lineEnding: LineEndingLF, want: "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "labels.csv")
+			l := validLabel()
+			if err := writeLabels(filename, []*ClickpostShippingLabel{&l, &l}, ExportOptions{LineEnding: tt.lineEnding}); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := bytes.Count(b, []byte(tt.want)); n != 3 {
+				t.Errorf("%qの数 = %d、3行分を期待: %q", tt.want, n, b)
+			}
+			if tt.want == "\n" && bytes.Contains(b, []byte("\r")) {
+				t.Errorf("LFのファイルにCRがあります: %q", b)
+			}
+		})
+	}
+}
+
+// TestWriteLabelsEncodings 3つの文字コードで書き込んだファイルは、戻すと同じ内容になる
+func TestWriteLabelsEncodings(t *testing.T) {
+	l := validLabel()
+	l.ShippingAddress3 = "渋谷マンション ①"
+	decoded := map[Encoding]string{}
+	for _, enc := range []Encoding{EncodingShiftJIS, EncodingUTF8BOM, EncodingUTF8} {
+		filename := filepath.Join(t.TempDir(), "labels.csv")
+		if err := writeLabels(filename, []*ClickpostShippingLabel{&l}, ExportOptions{Encoding: enc}); err != nil {
+			t.Fatalf("%s: %v", enc, err)
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bom := bytes.HasPrefix(b, []byte("\xef\xbb\xbf"))
+		switch enc {
+		case EncodingShiftJIS:
+			if b, err = japanese.ShiftJIS.NewDecoder().Bytes(b); err != nil {
+				t.Fatal(err)
+			}
+		case EncodingUTF8BOM:
+			if !bom {
+				t.Errorf("%s: BOMがありません", enc)
+			}
+			b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+		case EncodingUTF8:
+			if bom {
+				t.Errorf("%s: BOMがあります", enc)
+			}
+		}
+		decoded[enc] = string(b)
+	}
+	if !strings.Contains(decoded[EncodingUTF8], "渋谷マンション ①") {
+		t.Errorf("UTF-8のファイルに住所3行目がありません: %q", decoded[EncodingUTF8])
+	}
+	for _, enc := range []Encoding{EncodingShiftJIS, EncodingUTF8BOM} {
+		if decoded[enc] != decoded[EncodingUTF8] {
+			t.Errorf("%sのファイル = %q、UTF-8と同じ%qを期待", enc, decoded[enc], decoded[EncodingUTF8])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ImportShopifyOrdersXLSX Excelで保存し直した注文データ（.xlsx）をインポート
+// 先頭のシートの1行目をヘッダー行として、CSVと同じ列名で注文データに対応付ける
+func ImportShopifyOrdersXLSX(filename string) ([]*ShopifyOrder, error) {
+	f, err := excelize.OpenFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrInputNotFound, filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInputParse, filename, err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInputParse, filename, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: %sが空です。ヘッダー行もありません", ErrInputParse, filename)
+	}
+	// CSVに変換して、CSVと同じ方法で読み込む
+	// Excelは行末の空のセルを省くので、ヘッダー行の列数に揃える
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, row := range rows {
+		for len(row) < len(rows[0]) {
+			row = append(row, "")
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	aliases, err := LoadHeaderAliases(filename)
+	if err != nil {
+		return nil, err
+	}
+	headers, orders, err := ParseShopifyCSV(&buf, InputEncodingUTF8, aliases)
+	if err := skipMalformedRows(filename, err); err != nil {
+		return nil, err
+	}
+	reportColumns(filename, headers)
+	return orders, nil
+}
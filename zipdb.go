@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/width"
+)
+
+// ZipAddress 郵便番号に対応する住所
+type ZipAddress struct {
+	Prefecture string // 都道府県
+	City       string // 市区町村
+}
+
+// ZipDB 郵便番号（ハイフンなし7桁）から住所を引く表
+type ZipDB map[string]ZipAddress
+
+// LoadZipDB 日本郵便の郵便番号データ（KEN_ALL.CSV、Shift-JIS）を読み込む
+// 表は数MBあるためバイナリには埋め込まず、使う場合だけファイルを指定して読み込む
+func LoadZipDB(filename string) (ZipDB, error) {
+	inFile, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer inFile.Close()
+	reader := csv.NewReader(transform.NewReader(inFile, japanese.ShiftJIS.NewDecoder()))
+	reader.FieldsPerRecord = -1
+	db := ZipDB{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("郵便番号データを読み込めません: %w", err)
+		}
+		// 2列目が郵便番号、6列目が都道府県、7列目が市区町村
+		if len(record) < 8 {
+			continue
+		}
+		// 同じ郵便番号が複数行ある場合は最初の行を使う
+		if _, ok := db[record[2]]; !ok {
+			db[record[2]] = ZipAddress{Prefecture: record[6], City: record[7]}
+		}
+	}
+	return db, nil
+}
+
+// normalizeZip 郵便番号を全角・ハイフンを除いた数字だけにする
+func normalizeZip(zip string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, width.Fold.String(zip))
+}
+
+// Lookup 郵便番号から住所を引く
+func (db ZipDB) Lookup(zip string) (ZipAddress, bool) {
+	a, ok := db[normalizeZip(zip)]
+	return a, ok
+}
+
+// CompleteAddresses 都道府県・市区町村が空欄の注文を郵便番号から補完する
+// 入力済みの都道府県・市区町村が郵便番号と食い違う場合は上書きせず、警告として返す
+func CompleteAddresses(orders []*ShopifyOrder, db ZipDB) []string {
+	var warnings []string
+	for _, o := range orders {
+		a, ok := db.Lookup(o.ShippingZip)
+		if !ok {
+			continue
+		}
+		if o.ShippingProvince == "" {
+			o.ShippingProvince = a.Prefecture
+		} else if o.ShippingProvince != a.Prefecture {
+			warnings = append(warnings, fmt.Sprintf("注文番号:%s 郵便番号%sは%sですが、都道府県は%sです", o.Name, o.ShippingZip, a.Prefecture, o.ShippingProvince))
+		}
+		if o.ShippingCity == "" {
+			o.ShippingCity = a.City
+		} else if !strings.HasPrefix(o.ShippingCity, a.City) && !strings.HasPrefix(a.City, o.ShippingCity) {
+			warnings = append(warnings, fmt.Sprintf("注文番号:%s 郵便番号%sは%sですが、市区町村は%sです", o.Name, o.ShippingZip, a.City, o.ShippingCity))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// UniqueZips エクスポートした送り状のお届け先郵便番号を、重複を除いて昇順に並べて返す
+// 郵便番号は変換で正規化した送り状の値を使う
+func UniqueZips(labels []*ClickpostShippingLabel) []string {
+	seen := map[string]bool{}
+	var zips []string
+	for _, l := range labels {
+		if l.ShippingZip != "" && !seen[l.ShippingZip] {
+			seen[l.ShippingZip] = true
+			zips = append(zips, l.ShippingZip)
+		}
+	}
+	sort.Strings(zips)
+	return zips
+}
+
+// WriteZipList お届け先郵便番号の一覧を1行に1件ずつ書き込む。運賃の見積もりに使う
+func WriteZipList(filename string, labels []*ClickpostShippingLabel) error {
+	zips := UniqueZips(labels)
+	return os.WriteFile(filename, []byte(strings.Join(zips, "\n")+"\n"), 0644)
+}